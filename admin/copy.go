@@ -0,0 +1,97 @@
+package admin
+
+import "reflect"
+
+// pointerKey identifies a non-nil pointer value.
+type pointerKey struct {
+	typ reflect.Type
+	ptr uintptr
+}
+
+// deepCopy returns a copy of v that shares no pointed to values,
+// slices or maps reachable through exported fields with v,
+// so decoding JSON into the copy doesn't modify v.
+// Unexported fields are copied shallowly, because they are not decoded.
+func deepCopy(v reflect.Value) reflect.Value {
+	return copier{copies: make(map[pointerKey]reflect.Value)}.value(v)
+}
+
+// copier implements deepCopy,
+// remembering the copies of pointers to handle cycles.
+type copier struct {
+	copies map[pointerKey]reflect.Value
+}
+
+func (c copier) value(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		key := pointerKey{v.Type(), v.Pointer()}
+		if p, ok := c.copies[key]; ok {
+			return p
+		}
+		p := reflect.New(v.Type().Elem())
+		c.copies[key] = p
+		p.Elem().Set(c.value(v.Elem()))
+		return p
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		i := reflect.New(v.Type()).Elem()
+		i.Set(c.value(v.Elem()))
+		return i
+
+	case reflect.Struct:
+		s := reflect.New(v.Type()).Elem()
+		s.Set(v)
+		c.fields(s)
+		return s
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		s := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			s.Index(i).Set(c.value(v.Index(i)))
+		}
+		return s
+
+	case reflect.Array:
+		a := reflect.New(v.Type()).Elem()
+		for i := range v.Len() {
+			a.Index(i).Set(c.value(v.Index(i)))
+		}
+		return a
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		m := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m.SetMapIndex(iter.Key(), c.value(iter.Value()))
+		}
+		return m
+	}
+	return v
+}
+
+// fields copies the exported fields of the addressable struct copy s in place.
+func (c copier) fields(s reflect.Value) {
+	for i := range s.NumField() {
+		field := s.Type().Field(i)
+		switch {
+		case field.Anonymous && !field.IsExported() && field.Type.Kind() == reflect.Struct:
+			// Its exported fields are promoted and settable
+			c.fields(s.Field(i))
+		case field.IsExported():
+			s.Field(i).Set(c.value(s.Field(i)))
+		}
+	}
+}
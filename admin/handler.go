@@ -0,0 +1,393 @@
+// Package admin implements an http.Handler to inspect the configuration
+// of the Loaders registered with dynconfig.Register at runtime, and
+// optionally to edit it.
+//
+// The Handler lists the registered loaders with their file, Status, file
+// Version and current configuration as JSON, with the values of fields
+// tagged secret:"true" or dynconfig:"secret" masked by dynconfig.Redacted.
+// It does not authenticate requests, so mount it behind the authentication
+// middleware of the application.
+//
+// Example:
+//
+//	loader := dynconfig.MustLoadAndWatch("config.json", dynconfig.LoadJSON[Config], dynconfig.SaveJSON[Config]("  "), nil, nil, nil)
+//	dynconfig.Register(loader)
+//
+//	http.Handle("/admin/config", requireAdmin(&admin.Handler{AllowWrites: true}))
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ungerik/go-dynconfig"
+)
+
+// maxBodySize limits the size of PUT and PATCH request bodies.
+const maxBodySize = 10 << 20
+
+// Handler is an http.Handler for the Loaders registered with
+// dynconfig.Register. The request path is ignored, so the Handler
+// can be mounted at any path. The zero value is a read-only Handler.
+//
+// GET without query parameters responds with a JSON array of LoaderInfo
+// for all registered loaders. GET with a file query parameter responds with
+// the LoaderInfo of the first registered Loader of that file, with its
+// Version as ETag header.
+//
+// If AllowWrites is true, PUT with a file query parameter replaces the
+// configuration of the Loader with the JSON request body, and PATCH merges
+// the JSON request body into the current configuration. Both load the file
+// and write it with SetIfVersion, so a concurrent change results in a
+// 409 Conflict instead of a lost update. With an If-Match header the file
+// must still have the version of an ETag returned by an earlier request,
+// or the request fails with 412 Precondition Failed.
+//
+// Secret fields that still have their redacted value in the request body
+// keep their current value with dynconfig.RestoreRedacted, so a
+// configuration read from the Handler can be edited and written back.
+// Use PATCH with the new value to change a secret field.
+// A PUT replacing a missing or invalid file has no secrets to restore,
+// so it is rejected if the request body still contains RedactedString.
+// Unknown fields in the request body are rejected.
+//
+// Responses and StatusInfo.LastError don't contain the messages of
+// internal errors, which can include file paths or file content,
+// only a description of the kind of error.
+type Handler struct {
+	// AllowWrites enables PUT and PATCH requests.
+	AllowWrites bool
+
+	// Logger logs the internal errors of failed requests if not nil.
+	Logger *slog.Logger
+}
+
+// LoaderInfo is the JSON representation of a registered Loader.
+type LoaderInfo struct {
+	// File is the configuration file of the Loader.
+	File string `json:"file"`
+	// Type is the Go type of the configuration.
+	Type string `json:"type,omitempty"`
+	// Version is the version of the current file content.
+	Version dynconfig.Version `json:"version,omitempty"`
+	// Status is the health of the Loader.
+	Status *StatusInfo `json:"status,omitempty"`
+	// Value is the current configuration returned by Get
+	// with the values of secret fields masked by dynconfig.Redacted.
+	Value any `json:"value,omitempty"`
+}
+
+// StatusInfo is the JSON representation of a dynconfig.Status.
+type StatusInfo struct {
+	Healthy       bool      `json:"healthy"`
+	Loaded        bool      `json:"loaded"`
+	Loads         int       `json:"loads"`
+	LastLoad      time.Time `json:"lastLoad,omitzero"`
+	FileModTime   time.Time `json:"fileModTime,omitzero"`
+	LastError     string    `json:"lastError,omitempty"` // See publicError
+	LastErrorTime time.Time `json:"lastErrorTime,omitzero"`
+	Degraded      bool      `json:"degraded"`
+	Fallback      bool      `json:"fallback"`
+	Watching      bool      `json:"watching"`
+	Closed        bool      `json:"closed"`
+}
+
+// newStatusInfo returns the StatusInfo of status.
+func newStatusInfo(status dynconfig.Status) *StatusInfo {
+	info := &StatusInfo{
+		Healthy:       status.Healthy(),
+		Loaded:        status.Loaded,
+		Loads:         status.Loads,
+		LastLoad:      status.LastLoad,
+		FileModTime:   status.FileModTime,
+		LastErrorTime: status.LastErrorTime,
+		Degraded:      status.Degraded,
+		Fallback:      status.Fallback,
+		Watching:      status.Watching,
+		Closed:        status.Closed,
+	}
+	if status.LastError != nil {
+		info.LastError = publicError(status.LastError)
+	}
+	return info
+}
+
+// newLoaderInfo returns the LoaderInfo of r with the Version
+// of the file content or version if not empty.
+func newLoaderInfo(r dynconfig.Reloader, version dynconfig.Version) LoaderInfo {
+	info := LoaderInfo{File: string(r.File())}
+	loader, ok := r.(dynconfig.AnyLoader)
+	if !ok {
+		return info
+	}
+	if version == "" {
+		// Omitted if the file can't be read
+		version, _ = loader.Version()
+	}
+	value := loader.GetAny()
+	info.Type = fmt.Sprintf("%T", value)
+	info.Version = version
+	info.Status = newStatusInfo(loader.Status())
+	info.Value = dynconfig.Redacted(value)
+	return info
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.get(w, r)
+	case http.MethodPut, http.MethodPatch:
+		if h.AllowWrites {
+			h.write(w, r, r.Method == http.MethodPatch)
+			return
+		}
+		fallthrough
+	default:
+		w.Header().Set("Allow", h.allow())
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+// allow returns the value of the Allow header.
+func (h *Handler) allow() string {
+	if h.AllowWrites {
+		return "GET, HEAD, PUT, PATCH"
+	}
+	return "GET, HEAD"
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	if !r.URL.Query().Has("file") {
+		reloaders := dynconfig.Registered()
+		infos := make([]LoaderInfo, len(reloaders))
+		for i, reloader := range reloaders {
+			infos[i] = newLoaderInfo(reloader, "")
+		}
+		writeJSON(w, http.StatusOK, infos)
+		return
+	}
+	reloader, err := findLoader(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	info := newLoaderInfo(reloader, "")
+	if info.Version != "" {
+		w.Header().Set("ETag", etag(info.Version))
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (h *Handler) write(w http.ResponseWriter, r *http.Request, patch bool) {
+	reloader, err := findLoader(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	loader, ok := reloader.(dynconfig.AnyLoader)
+	if !ok {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s is not a dynconfig.Loader", reloader.File()))
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	current, version, err := loader.LoadVersionedAny()
+	if err != nil {
+		if patch {
+			h.writeInternalError(w, r, http.StatusInternalServerError, "can't patch configuration", err)
+			return
+		}
+		// PUT can replace a missing or invalid file
+		current = nil
+		version, err = loader.Version()
+		if err != nil {
+			h.writeInternalError(w, r, http.StatusInternalServerError, "can't read configuration", err)
+			return
+		}
+	}
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && !etagMatches(ifMatch, version) {
+		writeError(w, http.StatusPreconditionFailed, fmt.Errorf("%w: %s was modified", dynconfig.ErrConflict, reloader.File()))
+		return
+	}
+
+	var decodeErr error
+	newVersion, err := loader.SetIfVersionAny(r.Context(), version, func(config any) error {
+		decodeErr = decode(config, current, body, patch)
+		return decodeErr
+	})
+	switch {
+	case decodeErr != nil:
+		writeError(w, http.StatusBadRequest, decodeErr)
+		return
+	case errors.Is(err, dynconfig.ErrConflict) && r.Header.Get("If-Match") != "":
+		h.writeInternalError(w, r, http.StatusPreconditionFailed, "can't write configuration", err)
+		return
+	case errors.Is(err, dynconfig.ErrConflict):
+		h.writeInternalError(w, r, http.StatusConflict, "can't write configuration", err)
+		return
+	case errors.Is(err, dynconfig.ErrLockTimeout), errors.Is(err, dynconfig.ErrClosed):
+		h.writeInternalError(w, r, http.StatusServiceUnavailable, "can't write configuration", err)
+		return
+	case err != nil:
+		h.writeInternalError(w, r, http.StatusInternalServerError, "can't write configuration", err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(newVersion))
+	writeJSON(w, http.StatusOK, newLoaderInfo(reloader, newVersion))
+}
+
+// decode unmarshals body into the pointer config, after the current
+// configuration if patch is true, and restores redacted secret fields
+// from current. Without a current configuration the body must not
+// contain RedactedString values.
+func decode(config, current any, body []byte, patch bool) error {
+	if patch && current != nil {
+		// Decode into a deep copy of current, so patching keeps the
+		// fields that aren't marshalled and doesn't modify the cached
+		// configuration
+		reflect.ValueOf(config).Elem().Set(deepCopy(reflect.ValueOf(current)))
+	}
+	if current == nil {
+		var value any
+		err := json.Unmarshal(body, &value)
+		if err == nil && containsRedacted(value) {
+			return fmt.Errorf("invalid request body: can't restore %s values without a valid configuration file", dynconfig.RedactedString)
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(config)
+	if err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	if dec.More() {
+		return errors.New("invalid request body: more than one JSON value")
+	}
+	if current != nil {
+		v := reflect.ValueOf(config).Elem()
+		if restored := dynconfig.RestoreRedacted(v.Interface(), current); restored != nil {
+			v.Set(reflect.ValueOf(restored))
+		}
+	}
+	return nil
+}
+
+// containsRedacted reports whether the decoded JSON value
+// contains the string RedactedString.
+func containsRedacted(value any) bool {
+	switch value := value.(type) {
+	case string:
+		return value == dynconfig.RedactedString
+	case []any:
+		return slices.ContainsFunc(value, containsRedacted)
+	case map[string]any:
+		for _, v := range value {
+			if containsRedacted(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findLoader returns the first registered Reloader
+// of the file query parameter of the request.
+func findLoader(r *http.Request) (dynconfig.Reloader, error) {
+	file := r.URL.Query().Get("file")
+	if file == "" {
+		return nil, errors.New("missing file query parameter")
+	}
+	for _, reloader := range dynconfig.Registered() {
+		if string(reloader.File()) == file {
+			return reloader, nil
+		}
+	}
+	return nil, fmt.Errorf("no loader registered for %s", file)
+}
+
+// etag returns version as quoted ETag header value.
+func etag(version dynconfig.Version) string {
+	return `"` + string(version) + `"`
+}
+
+// etagMatches reports whether the If-Match header value ifMatch
+// matches version, where * matches every existing file.
+func etagMatches(ifMatch string, version dynconfig.Version) bool {
+	for tag := range strings.SplitSeq(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" && version != "" || tag == etag(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSON writes v as indented JSON response with status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(data, '\n'))
+}
+
+// publicError returns a description of the kind of err
+// without the message of err, which can contain file paths or
+// the content of files.
+func publicError(err error) string {
+	for _, known := range []error{
+		dynconfig.ErrConflict,
+		dynconfig.ErrLockTimeout,
+		dynconfig.ErrClosed,
+		dynconfig.ErrInterpolated,
+		fs.ErrNotExist,
+		fs.ErrPermission,
+	} {
+		if errors.Is(err, known) {
+			return known.Error()
+		}
+	}
+	return "internal error"
+}
+
+// writeInternalError logs err and writes msg with the publicError of err
+// as JSON object with an error string and status.
+func (h *Handler) writeInternalError(w http.ResponseWriter, r *http.Request, status int, msg string, err error) {
+	if h.Logger != nil {
+		h.Logger.LogAttrs(r.Context(), slog.LevelError, "admin request failed",
+			slog.String("method", r.Method),
+			slog.String("file", r.URL.Query().Get("file")),
+			slog.Any("error", err),
+		)
+	}
+	writeError(w, status, fmt.Errorf("%s: %s", msg, publicError(err)))
+}
+
+// writeError writes err as JSON object with an error string and status.
+func writeError(w http.ResponseWriter, status int, err error) {
+	data, _ := json.Marshal(struct {
+		Error string `json:"error"`
+	}{err.Error()})
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(data, '\n'))
+}
@@ -0,0 +1,361 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ungerik/go-dynconfig"
+	"github.com/ungerik/go-fs"
+)
+
+type Database struct {
+	Host     string `json:"host"`
+	Password string `json:"password" dynconfig:"secret"`
+}
+
+type Config struct {
+	Name     string            `json:"name"`
+	Port     int               `json:"port"`
+	APIKey   string            `json:"apiKey" secret:"true"`
+	Database Database          `json:"database"`
+	Tokens   map[string]string `json:"tokens,omitempty"`
+}
+
+const configJSON = `{
+	"name": "app",
+	"port": 8080,
+	"apiKey": "key-123",
+	"database": {"host": "db", "password": "hunter2"}
+}`
+
+// newTestLoader registers a Loader of a temporary config file
+// that is closed and unregistered when the test finishes.
+func newTestLoader(t *testing.T) (*dynconfig.Loader[Config], fs.File) {
+	t.Helper()
+	file := fs.File(filepath.Join(t.TempDir(), "config.json"))
+	err := file.WriteAllString(configJSON)
+	if err != nil {
+		t.Fatalf("write file: %s", err)
+	}
+	loader := dynconfig.NewLoader(file, dynconfig.LoadJSON[Config], dynconfig.SaveJSON[Config]("  "), nil, nil, nil)
+	dynconfig.Register(loader)
+	t.Cleanup(func() { loader.Close() })
+	return loader, file
+}
+
+// serve sends a request to handler and returns the response recorder.
+func serve(t *testing.T, handler http.Handler, method string, file fs.File, body string, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	target := "/admin/config"
+	if file != "" {
+		target += "?file=" + url.QueryEscape(string(file))
+	}
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+// decodeInfo decodes the LoaderInfo response with a Config value.
+func decodeInfo(t *testing.T, w *httptest.ResponseRecorder) (info LoaderInfo, config Config) {
+	t.Helper()
+	info.Value = &config
+	err := json.Unmarshal(w.Body.Bytes(), &info)
+	if err != nil {
+		t.Fatalf("decode response %q: %s", w.Body, err)
+	}
+	return info, config
+}
+
+func TestHandler_Get(t *testing.T) {
+	loader, file := newTestLoader(t)
+	handler := &Handler{}
+
+	w := serve(t, handler, http.MethodGet, "", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET list status = %d: %s", w.Code, w.Body)
+	}
+	var infos []LoaderInfo
+	err := json.Unmarshal(w.Body.Bytes(), &infos)
+	if err != nil {
+		t.Fatalf("decode list: %s", err)
+	}
+	if len(infos) != 1 || infos[0].File != string(file) {
+		t.Fatalf("GET list = %+v, want one loader of %s", infos, file)
+	}
+	if infos[0].Type != "admin.Config" {
+		t.Errorf("Type = %q, want admin.Config", infos[0].Type)
+	}
+	if infos[0].Status == nil || !infos[0].Status.Healthy || infos[0].Status.Loads != 1 {
+		t.Errorf("Status = %+v, want healthy after one load", infos[0].Status)
+	}
+	if strings.Contains(w.Body.String(), "hunter2") || strings.Contains(w.Body.String(), "key-123") {
+		t.Errorf("GET list leaks secrets:\n%s", w.Body)
+	}
+
+	w = serve(t, handler, http.MethodGet, file, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET status = %d: %s", w.Code, w.Body)
+	}
+	info, config := decodeInfo(t, w)
+	version, err := loader.Version()
+	if err != nil {
+		t.Fatalf("Version: %s", err)
+	}
+	if info.Version != version || w.Header().Get("ETag") != `"`+string(version)+`"` {
+		t.Errorf("Version = %q, ETag = %s, want %q", info.Version, w.Header().Get("ETag"), version)
+	}
+	want := Config{
+		Name:     "app",
+		Port:     8080,
+		APIKey:   dynconfig.RedactedString,
+		Database: Database{Host: "db", Password: dynconfig.RedactedString},
+	}
+	if config.Name != want.Name || config.Port != want.Port || config.APIKey != want.APIKey || config.Database != want.Database {
+		t.Errorf("GET value = %+v, want %+v", config, want)
+	}
+	if got := loader.Get(); got.APIKey != "key-123" || got.Database.Password != "hunter2" {
+		t.Errorf("redaction modified the cached configuration: %+v", got)
+	}
+
+	w = serve(t, handler, http.MethodGet, "/does/not/exist.json", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GET unknown file status = %d, want 404", w.Code)
+	}
+}
+
+func TestHandler_ReadOnly(t *testing.T) {
+	_, file := newTestLoader(t)
+	handler := &Handler{}
+
+	for _, method := range []string{http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete} {
+		w := serve(t, handler, method, file, `{"port": 9090}`)
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s status = %d, want 405", method, w.Code)
+		}
+		if allow := w.Header().Get("Allow"); allow != "GET, HEAD" {
+			t.Errorf("%s Allow = %q, want GET, HEAD", method, allow)
+		}
+	}
+	data, err := file.ReadAllString()
+	if err != nil {
+		t.Fatalf("read file: %s", err)
+	}
+	if data != configJSON {
+		t.Errorf("read-only handler modified the file:\n%s", data)
+	}
+}
+
+func TestHandler_Patch(t *testing.T) {
+	loader, file := newTestLoader(t)
+	handler := &Handler{AllowWrites: true}
+
+	w := serve(t, handler, http.MethodPatch, file, `{"port": 9090, "database": {"host": "db2"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PATCH status = %d: %s", w.Code, w.Body)
+	}
+	info, config := decodeInfo(t, w)
+	if config.Port != 9090 || config.Database.Host != "db2" || config.Name != "app" {
+		t.Errorf("PATCH value = %+v, want port 9090 and host db2", config)
+	}
+	if w.Header().Get("ETag") != `"`+string(info.Version)+`"` {
+		t.Errorf("ETag = %s, want version %q", w.Header().Get("ETag"), info.Version)
+	}
+
+	reloaded, err := dynconfig.LoadJSON[Config](file)
+	if err != nil {
+		t.Fatalf("LoadJSON: %s", err)
+	}
+	want := Config{Name: "app", Port: 9090, APIKey: "key-123", Database: Database{Host: "db2", Password: "hunter2"}}
+	if reloaded.Name != want.Name || reloaded.Port != want.Port || reloaded.APIKey != want.APIKey || reloaded.Database != want.Database {
+		t.Errorf("on-disk config = %+v, want %+v", reloaded, want)
+	}
+	if got := loader.Get(); got.Port != 9090 {
+		t.Errorf("cached port = %d, want 9090", got.Port)
+	}
+
+	// Change a secret
+	w = serve(t, handler, http.MethodPatch, file, `{"database": {"password": "correct horse"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PATCH secret status = %d: %s", w.Code, w.Body)
+	}
+	if got := loader.Get().Database.Password; got != "correct horse" {
+		t.Errorf("password = %q, want changed by PATCH", got)
+	}
+
+	w = serve(t, handler, http.MethodPatch, file, `{"prot": 1}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("PATCH with unknown field status = %d, want 400", w.Code)
+	}
+	w = serve(t, handler, http.MethodPatch, file, `{"port": "high"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("PATCH with invalid JSON status = %d, want 400", w.Code)
+	}
+}
+
+// TestHandler_PutRedacted verifies that a configuration read from the
+// Handler can be written back without overwriting the redacted secrets.
+func TestHandler_PutRedacted(t *testing.T) {
+	loader, file := newTestLoader(t)
+	handler := &Handler{AllowWrites: true}
+
+	w := serve(t, handler, http.MethodGet, file, "")
+	_, config := decodeInfo(t, w)
+	config.Port = 9191
+	body, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Marshal: %s", err)
+	}
+
+	w = serve(t, handler, http.MethodPut, file, string(body), "If-Match", w.Header().Get("ETag"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d: %s", w.Code, w.Body)
+	}
+	got := loader.Get()
+	if got.Port != 9191 || got.APIKey != "key-123" || got.Database.Password != "hunter2" {
+		t.Errorf("config after PUT = %+v, want port 9191 and unchanged secrets", got)
+	}
+}
+
+func TestHandler_IfMatch(t *testing.T) {
+	loader, file := newTestLoader(t)
+	handler := &Handler{AllowWrites: true}
+
+	w := serve(t, handler, http.MethodGet, file, "")
+	staleETag := w.Header().Get("ETag")
+	err := loader.Mutate(true, func(c Config) (Config, error) {
+		c.Name = "changed"
+		return c, nil
+	})
+	if err != nil {
+		t.Fatalf("Mutate: %s", err)
+	}
+
+	w = serve(t, handler, http.MethodPatch, file, `{"port": 1}`, "If-Match", staleETag)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("PATCH with stale If-Match status = %d, want 412: %s", w.Code, w.Body)
+	}
+	if got := loader.Get(); got.Port != 8080 || got.Name != "changed" {
+		t.Errorf("config = %+v, want unchanged by the failed PATCH", got)
+	}
+
+	w = serve(t, handler, http.MethodPatch, file, `{"port": 1}`, "If-Match", "*")
+	if w.Code != http.StatusOK {
+		t.Fatalf("PATCH with If-Match * status = %d: %s", w.Code, w.Body)
+	}
+	if got := loader.Get(); got.Port != 1 || got.Name != "changed" {
+		t.Errorf("config = %+v, want port 1 and name changed", got)
+	}
+}
+
+func TestHandler_PutInvalidFile(t *testing.T) {
+	loader, file := newTestLoader(t)
+	handler := &Handler{AllowWrites: true}
+
+	err := file.WriteAllString("{broken")
+	if err != nil {
+		t.Fatalf("write file: %s", err)
+	}
+	w := serve(t, handler, http.MethodPatch, file, `{"port": 1}`)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("PATCH of invalid file status = %d, want 500", w.Code)
+	}
+	w = serve(t, handler, http.MethodPut, file, `{"name": "fixed", "port": 1}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT replacing invalid file status = %d: %s", w.Code, w.Body)
+	}
+	if got := loader.Get(); got.Name != "fixed" || got.Port != 1 {
+		t.Errorf("config after PUT = %+v", got)
+	}
+}
+
+func TestHandler_PutRedactedInvalidFile(t *testing.T) {
+	loader, file := newTestLoader(t)
+	handler := &Handler{AllowWrites: true}
+
+	err := file.WriteAllString("{broken")
+	if err != nil {
+		t.Fatalf("write file: %s", err)
+	}
+	w := serve(t, handler, http.MethodPut, file, `{"name": "fixed", "apiKey": "[REDACTED]"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("PUT with redacted value replacing invalid file status = %d, want 400: %s", w.Code, w.Body)
+	}
+	data, err := file.ReadAllString()
+	if err != nil {
+		t.Fatalf("read file: %s", err)
+	}
+	if data != "{broken" {
+		t.Errorf("rejected PUT modified the file:\n%s", data)
+	}
+	if got := loader.Get(); got.APIKey == dynconfig.RedactedString {
+		t.Errorf("config after rejected PUT = %+v", got)
+	}
+}
+
+func TestHandler_ErrorsWithoutDetails(t *testing.T) {
+	loader, file := newTestLoader(t)
+	handler := &Handler{AllowWrites: true}
+
+	err := file.Remove()
+	if err != nil {
+		t.Fatalf("remove file: %s", err)
+	}
+	_, err = loader.Load()
+	if err == nil {
+		t.Fatal("Load of removed file succeeded")
+	}
+
+	w := serve(t, handler, http.MethodPatch, file, `{"port": 1}`)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("PATCH of removed file status = %d, want 500", w.Code)
+	}
+	if strings.Contains(w.Body.String(), file.Dir().LocalPath()) {
+		t.Errorf("PATCH error contains the file path: %s", w.Body)
+	}
+
+	w = serve(t, handler, http.MethodGet, file, "")
+	info, _ := decodeInfo(t, w)
+	if info.Status == nil || info.Status.LastError == "" {
+		t.Fatalf("Status = %+v, want LastError", info.Status)
+	}
+	if strings.Contains(info.Status.LastError, file.Dir().LocalPath()) {
+		t.Errorf("LastError contains the file path: %q", info.Status.LastError)
+	}
+}
+
+// TestDecode_PatchCopy verifies that PATCH keeps fields
+// that aren't marshalled and doesn't modify the current configuration.
+func TestDecode_PatchCopy(t *testing.T) {
+	type patchConfig struct {
+		Port     int               `json:"port"`
+		Tokens   map[string]string `json:"tokens"`
+		Database *Database         `json:"database"`
+		Internal string            `json:"-"`
+	}
+	current := patchConfig{
+		Port:     1,
+		Tokens:   map[string]string{"a": "1"},
+		Database: &Database{Host: "db", Password: "hunter2"},
+		Internal: "keep",
+	}
+	var config patchConfig
+	err := decode(&config, current, []byte(`{"tokens": {"b": "2"}, "database": {"host": "db2"}}`), true)
+	if err != nil {
+		t.Fatalf("decode: %s", err)
+	}
+	if config.Internal != "keep" || config.Port != 1 || len(config.Tokens) != 2 || config.Database.Host != "db2" || config.Database.Password != "hunter2" {
+		t.Errorf("patched config = %+v, %+v", config, config.Database)
+	}
+	if len(current.Tokens) != 1 || current.Database.Host != "db" {
+		t.Errorf("PATCH modified the current config: %+v, %+v", current, current.Database)
+	}
+}
@@ -0,0 +1,82 @@
+package dynconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// AnyLoader is implemented by every *Loader[T] to inspect and edit the
+// configuration without knowing its type T, for tools that handle all
+// loaders returned by Registered, like the admin package.
+type AnyLoader interface {
+	Reloader
+
+	// Status returns the health of the Loader.
+	Status() Status
+	// Version returns the Version of the current content of the file.
+	Version() (Version, error)
+	// GetAny returns the result of Get as any.
+	GetAny() any
+	// LoadVersionedAny returns the result of LoadVersioned
+	// with the configuration as any.
+	LoadVersionedAny() (config any, version Version, err error)
+	// SetIfVersionAny passes a pointer to a new zero value of T to decode
+	// and writes the decoded configuration with SetIfVersionContext.
+	SetIfVersionAny(ctx context.Context, expected Version, decode func(config any) error) (Version, error)
+}
+
+var _ AnyLoader = (*Loader[any])(nil)
+
+// Version returns the Version of the current content of the configuration
+// file, or an empty Version if it does not exist, without loading it.
+//
+// Note that the cached configuration returned by Get may be older than the
+// file if it is not watched. Use LoadVersioned to get a configuration
+// together with the Version it was loaded from.
+//
+// Safe to call on a nil Loader (returns an error). Thread-safe.
+func (l *Loader[T]) Version() (Version, error) {
+	if l == nil {
+		return "", errors.New("<nil> Loader")
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return l.fileVersion(context.Background())
+}
+
+// GetAny returns the result of Get as any, see AnyLoader.
+//
+// Safe to call on a nil Loader (returns nil). Thread-safe.
+func (l *Loader[T]) GetAny() any {
+	if l == nil {
+		return nil
+	}
+	return l.Get()
+}
+
+// LoadVersionedAny returns the result of LoadVersioned
+// with the configuration as any, see AnyLoader.
+//
+// Safe to call on a nil Loader (returns an error). Thread-safe.
+func (l *Loader[T]) LoadVersionedAny() (config any, version Version, err error) {
+	return l.LoadVersioned()
+}
+
+// SetIfVersionAny calls decode with a pointer to a new zero value of T,
+// for example to unmarshal JSON into it, and writes the decoded
+// configuration with SetIfVersionContext, see AnyLoader.
+//
+// Safe to call on a nil Loader (returns an error). Thread-safe.
+func (l *Loader[T]) SetIfVersionAny(ctx context.Context, expected Version, decode func(config any) error) (Version, error) {
+	if l == nil {
+		return "", errors.New("<nil> Loader")
+	}
+	var config T
+	err := decode(&config)
+	if err != nil {
+		return "", fmt.Errorf("SetIfVersion() decode error: %w", err)
+	}
+	return l.SetIfVersionContext(ctx, expected, config)
+}
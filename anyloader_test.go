@@ -0,0 +1,59 @@
+package dynconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestAnyLoader(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value": 1}`)
+	var loader AnyLoader = NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+
+	if got, ok := loader.GetAny().(counter); !ok || got.Value != 1 {
+		t.Fatalf("GetAny = %#v, want counter{Value: 1}", loader.GetAny())
+	}
+	config, version, err := loader.LoadVersionedAny()
+	if err != nil {
+		t.Fatalf("LoadVersionedAny: %s", err)
+	}
+	if got, ok := config.(counter); !ok || got.Value != 1 {
+		t.Errorf("LoadVersionedAny = %#v, want counter{Value: 1}", config)
+	}
+	current, err := loader.Version()
+	if err != nil {
+		t.Fatalf("Version: %s", err)
+	}
+	if current != version {
+		t.Errorf("Version = %q, want %q from LoadVersionedAny", current, version)
+	}
+
+	newVersion, err := loader.SetIfVersionAny(context.Background(), version, func(config any) error {
+		return json.Unmarshal([]byte(`{"value": 2}`), config)
+	})
+	if err != nil {
+		t.Fatalf("SetIfVersionAny: %s", err)
+	}
+	if got := readValue(t, file.LocalPath()); got != 2 {
+		t.Errorf("on-disk value = %d, want 2", got)
+	}
+	if current, _ = loader.Version(); current != newVersion {
+		t.Errorf("Version = %q, want %q from SetIfVersionAny", current, newVersion)
+	}
+
+	decodeErr := errors.New("decode failed")
+	_, err = loader.SetIfVersionAny(context.Background(), newVersion, func(any) error { return decodeErr })
+	if !errors.Is(err, decodeErr) {
+		t.Errorf("SetIfVersionAny error = %v, want the decode error", err)
+	}
+	_, err = loader.SetIfVersionAny(context.Background(), version, func(config any) error {
+		return json.Unmarshal([]byte(`{"value": 3}`), config)
+	})
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("SetIfVersionAny with stale version error = %v, want ErrConflict", err)
+	}
+	if got := readValue(t, file.LocalPath()); got != 2 {
+		t.Errorf("on-disk value = %d, want 2", got)
+	}
+}
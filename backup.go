@@ -0,0 +1,288 @@
+package dynconfig
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ungerik/go-fs"
+)
+
+// backupTimeLayout is the suffix layout of timestamped backups,
+// sortable and valid in file names on all platforms.
+const backupTimeLayout = "20060102T150405.000000000Z"
+
+// WithBackups keeps the last n versions of the configuration file when Set,
+// Mutate or Rollback replace it, as numbered files in the same directory:
+// config.json.1 is the version before the last write, config.json.2 the
+// one before that, and so on up to config.json.n.
+//
+// The backups are rotated under the same lock as the write itself
+// and only on the atomic write path for local files (see Mutate).
+// Writes to non-local file systems or on platforms without file locking
+// don't create backups. Use Rollback to restore a backup.
+//
+// Example:
+//
+//	loader := dynconfig.MustLoadAndWatch(
+//	    "config.json",
+//	    dynconfig.LoadJSON[Config],
+//	    dynconfig.SaveJSON[Config]("  "),
+//	    nil, nil, nil,
+//	    dynconfig.WithBackups(5),
+//	)
+func WithBackups(n int) Option {
+	return func(o *loaderOptions) {
+		o.backups = n
+		o.backupTimestamps = false
+	}
+}
+
+// WithTimestampedBackups works like WithBackups but names the backups
+// after the UTC time they were made, like config.json.20260102T150405.000000000Z,
+// and deletes all but the newest n.
+func WithTimestampedBackups(n int) Option {
+	return func(o *loaderOptions) {
+		o.backups = n
+		o.backupTimestamps = true
+	}
+}
+
+// Backups returns the backups of the configuration file made because of
+// WithBackups or WithTimestampedBackups, newest first, so Backups()[n-1]
+// is restored by Rollback(n).
+//
+// Safe to call on a nil Loader (returns an error). Thread-safe.
+func (l *Loader[T]) Backups() ([]fs.File, error) {
+	if l == nil {
+		return nil, errors.New("<nil> Loader")
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	localPath := l.file.LocalPath()
+	if localPath == "" {
+		return nil, nil
+	}
+	paths, err := l.backupPaths(localPath)
+	if err != nil {
+		return nil, err
+	}
+	backups := make([]fs.File, len(paths))
+	for i, path := range paths {
+		backups[i] = fs.File(path)
+	}
+	return backups, nil
+}
+
+// Rollback restores the n-th newest backup of the configuration file,
+// with Rollback(1) restoring the version before the last write.
+//
+// The backup is validated with the Loader's load function and then copied
+// byte for byte over the configuration file using the same lock
+// and atomic rename as Set. The replaced version becomes a backup itself,
+// so a Rollback can be undone with another Rollback(1).
+// The cached configuration is invalidated, so the next Get or Load reads
+// the restored file.
+//
+// Rollback requires a local file on a platform with file locking and a
+// Loader created with WithBackups or WithTimestampedBackups.
+//
+// Safe to call on a nil Loader (returns an error). Thread-safe.
+//
+// Example:
+//
+//	if err := loader.Rollback(1); err != nil {
+//	    log.Printf("Rollback failed: %v", err)
+//	}
+func (l *Loader[T]) Rollback(n int) (err error) {
+	if l == nil {
+		return errors.New("<nil> Loader")
+	}
+	if n < 1 {
+		return fmt.Errorf("Rollback() invalid backup number %d", n)
+	}
+	start := time.Now()
+	var version Version
+	defer func() { l.observeWrite(context.Background(), "Rollback", start, version, err) }()
+	defer func() {
+		if err == nil && l.subscribed() {
+			// Load errors are handled by onError and the logger
+			_, _ = l.Load()
+		}
+	}()
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if l.closed {
+		return fmt.Errorf("Rollback() %w", ErrClosed)
+	}
+	if l.opts.backups <= 0 {
+		return errors.New("Rollback() requires backups enabled with WithBackups or WithTimestampedBackups")
+	}
+	atomic, localPath, release, e := l.lockForWrite(context.Background())
+	if e != nil {
+		return fmt.Errorf("Rollback() %w", e)
+	}
+	defer func() { err = errors.Join(err, release()) }()
+	l.observeLockWait(context.Background(), "Rollback", start)
+	if !atomic {
+		return fmt.Errorf("Rollback() requires a local file system with file locking: %s", l.file)
+	}
+
+	paths, e := l.backupPaths(localPath)
+	if e != nil {
+		return fmt.Errorf("Rollback() %w", e)
+	}
+	if n > len(paths) {
+		return fmt.Errorf("Rollback() backup %d not found, %d available", n, len(paths))
+	}
+	backup := paths[n-1]
+	_, e = l.load(context.Background(), fs.File(backup))
+	if e != nil {
+		return fmt.Errorf("Rollback() invalid backup %s: %w", backup, e)
+	}
+	e = l.replaceAtomic(localPath, func(tmpPath string) error {
+		return copyFileContent(backup, tmpPath)
+	})
+	if e != nil {
+		return fmt.Errorf("Rollback() %w", e)
+	}
+	version = l.metricsVersion(context.Background(), nil)
+	l.loaded = false
+	return nil
+}
+
+// backup makes a backup of the existing file at localPath and rotates or
+// prunes older backups according to the options. It does nothing if backups
+// are disabled or the file does not exist. The caller must hold l.mtx and
+// the write lock.
+func (l *Loader[T]) backup(localPath string) error {
+	if l.opts.backups <= 0 {
+		return nil
+	}
+	if _, err := os.Lstat(localPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if l.opts.backupTimestamps {
+		err := linkOrCopyFile(localPath, localPath+"."+time.Now().UTC().Format(backupTimeLayout))
+		if err != nil {
+			return fmt.Errorf("backup error: %w", err)
+		}
+		paths, err := l.backupPaths(localPath)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths[min(l.opts.backups, len(paths)):] {
+			err = os.Remove(path)
+			if err != nil {
+				return fmt.Errorf("remove old backup error: %w", err)
+			}
+		}
+		return nil
+	}
+
+	numbered := func(i int) string { return localPath + "." + strconv.Itoa(i) }
+	err := os.Remove(numbered(l.opts.backups))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove old backup error: %w", err)
+	}
+	for i := l.opts.backups - 1; i >= 1; i-- {
+		err = os.Rename(numbered(i), numbered(i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("rotate backup error: %w", err)
+		}
+	}
+	err = linkOrCopyFile(localPath, numbered(1))
+	if err != nil {
+		return fmt.Errorf("backup error: %w", err)
+	}
+	return nil
+}
+
+// backupPaths returns the paths of the existing backups of localPath of the
+// kind configured by the options, newest first.
+func (l *Loader[T]) backupPaths(localPath string) ([]string, error) {
+	dir, name := filepath.Split(localPath)
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil, err
+	}
+	type backup struct {
+		path  string
+		order int64 // Higher is older
+	}
+	var backups []backup
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), name+".")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if l.opts.backupTimestamps {
+			t, err := time.Parse(backupTimeLayout, suffix)
+			if err == nil {
+				backups = append(backups, backup{filepath.Join(dir, entry.Name()), -t.UnixNano()})
+			}
+		} else {
+			i, err := strconv.Atoi(suffix)
+			if err == nil && i > 0 && strconv.Itoa(i) == suffix {
+				backups = append(backups, backup{filepath.Join(dir, entry.Name()), int64(i)})
+			}
+		}
+	}
+	slices.SortFunc(backups, func(a, b backup) int { return cmp.Compare(a.order, b.order) })
+	paths := make([]string, len(backups))
+	for i, b := range backups {
+		paths[i] = b.path
+	}
+	return paths, nil
+}
+
+// linkOrCopyFile makes dst a hard link to src, keeping the inode that the
+// atomic rename is about to unlink from the configuration file name.
+// It falls back to copying the content if src is a symbolic link or the
+// file system does not support hard links.
+func linkOrCopyFile(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink == 0 && os.Link(src, dst) == nil {
+		return nil
+	}
+	return copyFileContent(src, dst)
+}
+
+// copyFileContent copies the content of src to dst, creating or truncating
+// dst with the permission bits of src.
+func copyFileContent(src, dst string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, in.Close()) }()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	return errors.Join(err, out.Close())
+}
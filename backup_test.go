@@ -0,0 +1,140 @@
+package dynconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ungerik/go-fs"
+)
+
+// readValue returns the counter value stored as JSON at path.
+func readValue(t *testing.T, path string) int {
+	t.Helper()
+	c, err := LoadJSON[counter](fs.File(path))
+	if err != nil {
+		t.Fatalf("LoadJSON(%s): %s", path, err)
+	}
+	return c.Value
+}
+
+func TestWithBackups(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value":0}`)
+	path := file.LocalPath()
+
+	loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil, WithBackups(2))
+	for i := 1; i <= 3; i++ {
+		if err := loader.Set(counter{Value: i}); err != nil {
+			t.Fatalf("Set: %s", err)
+		}
+	}
+	if got := readValue(t, path); got != 3 {
+		t.Errorf("file value = %d, want 3", got)
+	}
+	if got := readValue(t, path+".1"); got != 2 {
+		t.Errorf(".1 value = %d, want 2", got)
+	}
+	if got := readValue(t, path+".2"); got != 1 {
+		t.Errorf(".2 value = %d, want 1", got)
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected no third backup, got %v", err)
+	}
+	backups, err := loader.Backups()
+	if err != nil {
+		t.Fatalf("Backups: %s", err)
+	}
+	if len(backups) != 2 || backups[0].LocalPath() != path+".1" || backups[1].LocalPath() != path+".2" {
+		t.Errorf("Backups() = %v", backups)
+	}
+
+	// Rollback restores .1 and makes the replaced version the new .1
+	if err := loader.Rollback(1); err != nil {
+		t.Fatalf("Rollback: %s", err)
+	}
+	if got := loader.Get().Value; got != 2 {
+		t.Errorf("after Rollback(1) got %d, want 2", got)
+	}
+	if got := readValue(t, path+".1"); got != 3 {
+		t.Errorf("after Rollback(1) .1 value = %d, want 3", got)
+	}
+	if err := loader.Rollback(1); err != nil {
+		t.Fatalf("Rollback: %s", err)
+	}
+	if got := loader.Get().Value; got != 3 {
+		t.Errorf("undoing Rollback got %d, want 3", got)
+	}
+	assertNoTempFiles(t, filepath.Dir(path))
+}
+
+func TestWithTimestampedBackups(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value":0}`)
+
+	loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil, WithTimestampedBackups(2))
+	for i := 1; i <= 4; i++ {
+		if err := loader.Mutate(true, func(c counter) (counter, error) {
+			c.Value++
+			return c, nil
+		}); err != nil {
+			t.Fatalf("Mutate: %s", err)
+		}
+	}
+	backups, err := loader.Backups()
+	if err != nil {
+		t.Fatalf("Backups: %s", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("got %d backups, want 2: %v", len(backups), backups)
+	}
+	if got := readValue(t, backups[0].LocalPath()); got != 3 {
+		t.Errorf("newest backup value = %d, want 3", got)
+	}
+	if got := readValue(t, backups[1].LocalPath()); got != 2 {
+		t.Errorf("second newest backup value = %d, want 2", got)
+	}
+	if err := loader.Rollback(2); err != nil {
+		t.Fatalf("Rollback: %s", err)
+	}
+	if got := loader.Get().Value; got != 2 {
+		t.Errorf("after Rollback(2) got %d, want 2", got)
+	}
+}
+
+func TestRollback_Errors(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value":1}`)
+	path := file.LocalPath()
+
+	withoutBackups := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+	if err := withoutBackups.Rollback(1); err == nil {
+		t.Error("expected error without backups option")
+	}
+
+	loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil, WithBackups(3))
+	if err := loader.Rollback(1); err == nil {
+		t.Error("expected error for missing backup")
+	}
+	if err := loader.Rollback(0); err == nil {
+		t.Error("expected error for backup number 0")
+	}
+	if err := os.WriteFile(path+".1", []byte("invalid"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	if err := loader.Rollback(1); err == nil {
+		t.Error("expected error for invalid backup")
+	}
+	if got := readValue(t, path); got != 1 {
+		t.Errorf("file value = %d, want unchanged 1", got)
+	}
+}
+
+// assertNoTempFiles fails if dir contains temporary files of the atomic save.
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, ".*.tmp-*"))
+	if err != nil {
+		t.Fatalf("Glob: %s", err)
+	}
+	if len(matches) > 0 {
+		t.Errorf("leftover temporary files: %v", matches)
+	}
+}
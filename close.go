@@ -0,0 +1,78 @@
+package dynconfig
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+)
+
+// ErrClosed is returned by the methods of a Loader after Close,
+// wrapped with the name of the method for Set, Mutate and related methods.
+var ErrClosed = errors.New("loader closed")
+
+var _ io.Closer = (*Loader[any])(nil)
+
+// Close stops watching the file and its dependencies, stops the signal
+// handlers started with ReloadOn, and removes the Loader from the registry
+// of Register, releasing all resources of the Loader.
+//
+// After Close, Load, Reload, Watch, Set, Mutate, SetIfVersion and Rollback
+// return ErrClosed. Get keeps returning the last loaded configuration
+// without reloading it, so code still holding the Loader keeps working.
+// Closing an already closed Loader does nothing and returns nil.
+//
+// Close implements io.Closer.
+//
+// Safe to call on a nil Loader (returns an error). Thread-safe.
+//
+// Example of a test using a Loader:
+//
+//	loader := dynconfig.MustLoadAndWatch("testdata/config.json", dynconfig.LoadJSON[Config], nil, nil, nil, nil)
+//	t.Cleanup(func() { loader.Close() })
+func (l *Loader[T]) Close() error {
+	if l == nil {
+		return errors.New("<nil> Loader")
+	}
+	l.mtx.Lock()
+	if l.closed {
+		l.mtx.Unlock()
+		return nil
+	}
+	l.closed = true
+	var err error
+	if l.unwatch != nil {
+		err = l.stopWatching()
+	}
+	onClose := l.onClose
+	l.onClose = nil
+	l.mtx.Unlock()
+	l.logAttrs(context.Background(), slog.LevelDebug, "config loader closed")
+
+	for _, fn := range onClose {
+		fn()
+	}
+	return err
+}
+
+// closeNotifier is implemented by Loader to let Register remove
+// a closed Loader from the registry.
+type closeNotifier interface {
+	addCloseFunc(fn func())
+}
+
+// addCloseFunc registers fn to be called by Close,
+// or calls it immediately if the Loader is already closed.
+func (l *Loader[T]) addCloseFunc(fn func()) {
+	if l == nil {
+		return
+	}
+	l.mtx.Lock()
+	if !l.closed {
+		l.onClose = append(l.onClose, fn)
+		l.mtx.Unlock()
+		return
+	}
+	l.mtx.Unlock()
+	fn()
+}
@@ -0,0 +1,146 @@
+package dynconfig
+
+import (
+	"errors"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ungerik/go-fs"
+)
+
+func TestLoader_Close(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value": 1}`)
+	loader, err := LoadAndWatch(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadAndWatch: %s", err)
+	}
+
+	err = loader.Close()
+	if err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+	err = loader.Close()
+	if err != nil {
+		t.Errorf("second Close: %s", err)
+	}
+
+	if got := loader.Get().Value; got != 1 {
+		t.Errorf("Get after Close = %d, want last loaded 1", got)
+	}
+	_, err = loader.Load()
+	if !errors.Is(err, ErrClosed) {
+		t.Errorf("Load error = %v, want ErrClosed", err)
+	}
+	if err = loader.Reload(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Reload error = %v, want ErrClosed", err)
+	}
+	if err = loader.Watch(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Watch error = %v, want ErrClosed", err)
+	}
+	if err = loader.Set(counter{Value: 2}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Set error = %v, want ErrClosed", err)
+	}
+	err = loader.Mutate(false, func(c counter) (counter, error) { return c, nil })
+	if !errors.Is(err, ErrClosed) {
+		t.Errorf("Mutate error = %v, want ErrClosed", err)
+	}
+	if _, err = loader.SetIfVersion("", counter{Value: 2}); !errors.Is(err, ErrClosed) {
+		t.Errorf("SetIfVersion error = %v, want ErrClosed", err)
+	}
+	if got := readValue(t, file.LocalPath()); got != 1 {
+		t.Errorf("on-disk value = %d, want unchanged 1", got)
+	}
+
+	// File changes are no longer watched
+	err = file.WriteAllString(`{"value": 3}`)
+	if err != nil {
+		t.Fatalf("write file: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got := loader.Get().Value; got != 1 {
+		t.Errorf("Get after file change = %d, want 1", got)
+	}
+
+	var nilLoader *Loader[counter]
+	if nilLoader.Close() == nil {
+		t.Error("Close on nil Loader must return an error")
+	}
+}
+
+func TestLoader_CloseUnregisters(t *testing.T) {
+	file := memFile(t, "counter.json", `{"value": 1}`)
+	loader := NewLoader(file, LoadJSON[counter], nil, nil, nil, nil)
+	unregister := Register(loader)
+	defer unregister()
+
+	err := loader.Close()
+	if err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+	if slices.Contains(Registered(), Reloader(loader)) {
+		t.Error("closed Loader still registered")
+	}
+}
+
+// TestLoader_CloseGoroutineLeak verifies that Close ends all goroutines
+// started for watching the file and its dependencies and for ReloadOn.
+func TestLoader_CloseGoroutineLeak(t *testing.T) {
+	dir := t.TempDir()
+	secret := fs.File(filepath.Join(dir, "secrets", "password"))
+	err := secret.Dir().MakeDir()
+	if err != nil {
+		t.Fatalf("MakeDir: %s", err)
+	}
+	err = secret.WriteAllString("hunter2")
+	if err != nil {
+		t.Fatalf("write secret: %s", err)
+	}
+	file := fs.File(filepath.Join(dir, "counter.json"))
+	err = file.WriteAllString(`{"value": 1}`)
+	if err != nil {
+		t.Fatalf("write file: %s", err)
+	}
+	load := func(file fs.File) (counter, error) {
+		ReportDependencies(file, secret)
+		return LoadJSON[counter](file)
+	}
+
+	openAndClose := func() {
+		t.Helper()
+		loader, err := LoadAndWatch(file, load, nil, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("LoadAndWatch: %s", err)
+		}
+		loader.ReloadOn(syscall.SIGUSR1)
+		Register(loader)
+		if got := len(loader.Dependencies()); got != 1 {
+			t.Fatalf("Dependencies = %d files, want 1", got)
+		}
+		err = loader.Close()
+		if err != nil {
+			t.Fatalf("Close: %s", err)
+		}
+	}
+
+	// The first watch and signal.Notify start process-wide goroutines
+	// of go-fs and os/signal that are not owned by any Loader
+	openAndClose()
+	before := runtime.NumGoroutine()
+	for range 5 {
+		openAndClose()
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<20)
+			t.Fatalf("goroutines after Close = %d, want at most %d\n%s",
+				runtime.NumGoroutine(), before, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
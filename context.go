@@ -0,0 +1,82 @@
+package dynconfig
+
+import (
+	"context"
+
+	"github.com/ungerik/go-fs"
+)
+
+// LoadContextFunc is a context-aware load function for NewLoaderContext and
+// LoadAndWatchContext. The context passed to LoadContext, MutateContext and
+// related Loader methods is passed through to it, so a load from a slow
+// remote go-fs file system can be canceled.
+// Loads triggered by Get, Load or other methods without a context
+// get context.Background().
+type LoadContextFunc[T any] func(ctx context.Context, file fs.File) (T, error)
+
+// SaveContextFunc is a context-aware save function for NewLoaderContext and
+// LoadAndWatchContext, called with the context passed to MutateContext,
+// SetContext or SetIfVersionContext.
+type SaveContextFunc[T any] func(ctx context.Context, file fs.File, config T) error
+
+// ContextLoad adapts a load function without context to a LoadContextFunc.
+// The returned function returns the context error without calling load if
+// the context has already ended. A nil load returns nil.
+//
+// Example using a text loader with NewLoaderContext:
+//
+//	loader := dynconfig.NewLoaderContext(
+//	    "allowlist.txt",
+//	    dynconfig.ContextLoad(dynconfig.LoadStringLineSet),
+//	    dynconfig.ContextSave(dynconfig.SaveStringLineSet()),
+//	    nil, nil, nil,
+//	)
+func ContextLoad[T any](load func(fs.File) (T, error)) LoadContextFunc[T] {
+	if load == nil {
+		return nil
+	}
+	return func(ctx context.Context, file fs.File) (T, error) {
+		if err := ctx.Err(); err != nil {
+			return *new(T), err
+		}
+		return load(file)
+	}
+}
+
+// ContextSave adapts a save function without context to a SaveContextFunc.
+// The returned function returns the context error without calling save if
+// the context has already ended. A nil save returns nil.
+func ContextSave[T any](save func(fs.File, T) error) SaveContextFunc[T] {
+	if save == nil {
+		return nil
+	}
+	return func(ctx context.Context, file fs.File, config T) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return save(file, config)
+	}
+}
+
+// BackgroundLoad adapts a LoadContextFunc to a load function without context
+// by calling it with context.Background(), for example to pass it to
+// Interpolate or BindFlags. A nil load returns nil.
+func BackgroundLoad[T any](load LoadContextFunc[T]) func(fs.File) (T, error) {
+	if load == nil {
+		return nil
+	}
+	return func(file fs.File) (T, error) {
+		return load(context.Background(), file)
+	}
+}
+
+// BackgroundSave adapts a SaveContextFunc to a save function without context
+// by calling it with context.Background(). A nil save returns nil.
+func BackgroundSave[T any](save SaveContextFunc[T]) func(fs.File, T) error {
+	if save == nil {
+		return nil
+	}
+	return func(file fs.File, config T) error {
+		return save(context.Background(), file, config)
+	}
+}
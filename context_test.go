@@ -0,0 +1,127 @@
+package dynconfig
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ungerik/go-fs"
+)
+
+type ctxKey struct{}
+
+func TestContextLoad_Canceled(t *testing.T) {
+	called := false
+	load := ContextLoad(func(fs.File) (counter, error) {
+		called = true
+		return counter{}, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := load(ctx, "counter.json")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+	if called {
+		t.Error("load called with a canceled context")
+	}
+	if ContextLoad[counter](nil) != nil || ContextSave[counter](nil) != nil {
+		t.Error("adapters of nil functions must be nil")
+	}
+}
+
+func TestLoader_ContextPassedToLoadAndSave(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value": 1}`)
+	var loadCtx, saveCtx []any
+	loader := NewLoaderContext(
+		file,
+		func(ctx context.Context, file fs.File) (counter, error) {
+			loadCtx = append(loadCtx, ctx.Value(ctxKey{}))
+			return LoadJSONContext[counter](ctx, file)
+		},
+		func(ctx context.Context, file fs.File, config counter) error {
+			saveCtx = append(saveCtx, ctx.Value(ctxKey{}))
+			return SaveJSONContext[counter]()(ctx, file, config)
+		},
+		nil, nil, nil,
+	)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "load")
+	config, err := loader.LoadContext(ctx)
+	if err != nil {
+		t.Fatalf("LoadContext: %s", err)
+	}
+	if config.Value != 1 {
+		t.Errorf("LoadContext value = %d, want 1", config.Value)
+	}
+	err = loader.MutateContext(context.WithValue(ctx, ctxKey{}, "mutate"), true, func(c counter) (counter, error) {
+		c.Value++
+		return c, nil
+	})
+	if err != nil {
+		t.Fatalf("MutateContext: %s", err)
+	}
+	err = loader.SetContext(context.WithValue(ctx, ctxKey{}, "set"), counter{Value: 5})
+	if err != nil {
+		t.Fatalf("SetContext: %s", err)
+	}
+
+	if want := []any{"load", "mutate"}; !slices.Equal(loadCtx, want) {
+		t.Errorf("load contexts = %v, want %v", loadCtx, want)
+	}
+	if want := []any{"mutate", "set"}; !slices.Equal(saveCtx, want) {
+		t.Errorf("save contexts = %v, want %v", saveCtx, want)
+	}
+	if got := readValue(t, file.LocalPath()); got != 5 {
+		t.Errorf("on-disk value = %d, want 5", got)
+	}
+}
+
+func TestSetContext_CanceledSave(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value": 1}`)
+	loader := NewLoaderContext(
+		file,
+		LoadJSONContext[counter],
+		func(ctx context.Context, file fs.File, config counter) error {
+			// A slow remote write that only ends with the context
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		nil, nil, nil,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := loader.SetContext(ctx, counter{Value: 2})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SetContext error = %v, want context.DeadlineExceeded", err)
+	}
+	if got := readValue(t, file.LocalPath()); got != 1 {
+		t.Errorf("on-disk value = %d, want unchanged 1", got)
+	}
+	assertNoTempFiles(t, filepath.Dir(file.LocalPath()))
+}
+
+func TestBackgroundLoad(t *testing.T) {
+	file := memFile(t, "counter.json", `{"value": 3}`)
+	loader := NewLoader(file, BackgroundLoad(LoadJSONContext[counter]), BackgroundSave(SaveJSONContext[counter]()), nil, nil, nil)
+
+	if got := loader.Get().Value; got != 3 {
+		t.Errorf("value = %d, want 3", got)
+	}
+	err := loader.Set(counter{Value: 4})
+	if err != nil {
+		t.Fatalf("Set: %s", err)
+	}
+	got, err := LoadJSON[counter](file)
+	if err != nil {
+		t.Fatalf("LoadJSON: %s", err)
+	}
+	if got.Value != 4 {
+		t.Errorf("file value = %d, want 4", got.Value)
+	}
+}
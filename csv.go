@@ -0,0 +1,447 @@
+package dynconfig
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/ungerik/go-fs"
+)
+
+// LoadCSV loads a CSV file with a header row into a slice of structs of type T,
+// one element per data row.
+//
+// The header row names the columns. Each column is mapped to the exported
+// field of T with the matching `csv:"name"` struct tag, or to the field with
+// the same name if it has no tag. Fields tagged `csv:"-"` are ignored, as are
+// columns without a matching field; fields without a column keep their zero
+// value. The fields of untagged embedded structs and struct pointers are
+// promoted like with encoding/json; nil embedded pointers are allocated
+// on load and written as empty cells.
+//
+// Cells are converted to the field types: strings, bools, signed and unsigned
+// integers, floats, time.Duration (e.g. "1h30m"), time.Time (RFC 3339),
+// pointers to those, and any type implementing encoding.TextUnmarshaler. An
+// empty cell sets the zero value (nil for pointers).
+//
+// T must be a struct or a pointer to a struct. An empty file yields an empty
+// slice.
+//
+// This is a loader function compatible with LoadAndWatch and MustLoadAndWatch.
+//
+// Example:
+//
+//	// prices.csv contains:
+//	// sku,price,active
+//	// A-1,9.99,true
+//	// B-2,19.50,false
+//
+//	type Price struct {
+//	    SKU    string  `csv:"sku"`
+//	    Price  float64 `csv:"price"`
+//	    Active bool    `csv:"active"`
+//	}
+//
+//	loader := dynconfig.MustLoadAndWatch(
+//	    "prices.csv",
+//	    dynconfig.LoadCSV[Price],
+//	    dynconfig.SaveCSV[Price],
+//	    nil, nil, nil,
+//	)
+func LoadCSV[T any](file fs.File) ([]T, error) {
+	return loadDelimited[T](file, ',')
+}
+
+// SaveCSV writes a slice of structs of type T to the file as CSV, overwriting
+// any existing content.
+//
+// A header row with the column names of T's fields (see LoadCSV for the
+// mapping) is written first, followed by one row per element in slice order.
+// Values are formatted so that LoadCSV reads them back unchanged.
+//
+// It is the write counterpart to LoadCSV and can be passed directly as the
+// save function to the constructor for use by Loader.Mutate and Loader.Set.
+//
+// Example:
+//
+//	err := loader.Mutate(false, func(prices []Price) ([]Price, error) {
+//	    return append(prices, Price{SKU: "C-3", Price: 5, Active: true}), nil
+//	})
+func SaveCSV[T any](file fs.File, config []T) error {
+	return saveDelimited(file, ',', config)
+}
+
+// LoadTSV loads a tab-separated file with a header row into a slice of structs
+// of type T. It works exactly like LoadCSV with a tab instead of a comma as
+// the field delimiter.
+func LoadTSV[T any](file fs.File) ([]T, error) {
+	return loadDelimited[T](file, '\t')
+}
+
+// SaveTSV writes a slice of structs of type T to the file as tab-separated
+// values, overwriting any existing content. It is the write counterpart to
+// LoadTSV and works exactly like SaveCSV with a tab as the field delimiter.
+func SaveTSV[T any](file fs.File, config []T) error {
+	return saveDelimited(file, '\t', config)
+}
+
+// LoadCSVMap returns a load function that loads a CSV file with a header row
+// into a map of structs of type T, keyed by the value of the keyColumn column.
+//
+// The key cell is converted to K with the same rules LoadCSV uses for fields.
+// The key column may additionally map to a field of T, which then holds the
+// key as well. Rows with duplicate keys are rejected with an error.
+//
+// Because it needs the key column as configuration, it is a factory returning
+// the load function rather than a load function itself.
+//
+// Example:
+//
+//	// tenants.csv contains:
+//	// id,name,quota
+//	// 1,acme,100
+//	// 2,globex,250
+//
+//	type Tenant struct {
+//	    Name  string `csv:"name"`
+//	    Quota int    `csv:"quota"`
+//	}
+//
+//	loader := dynconfig.MustLoadAndWatch(
+//	    "tenants.csv",
+//	    dynconfig.LoadCSVMap[int, Tenant]("id"),
+//	    dynconfig.SaveCSVMap[int, Tenant]("id"),
+//	    nil, nil, nil,
+//	)
+//	acme := loader.Get()[1]
+func LoadCSVMap[K cmp.Ordered, T any](keyColumn string) func(file fs.File) (map[K]T, error) {
+	return func(file fs.File) (map[K]T, error) {
+		return loadDelimitedMap[K, T](file, ',', keyColumn)
+	}
+}
+
+// SaveCSVMap returns a save function that writes a map of structs of type T to
+// the file as CSV, overwriting any existing content.
+//
+// The key is written as the first column named keyColumn, followed by the
+// columns of T (see LoadCSV). A field of T mapped to keyColumn is not written a
+// second time: the map key is authoritative. Rows are sorted by key so the
+// output is deterministic regardless of map iteration order.
+//
+// It is the write counterpart to LoadCSVMap.
+func SaveCSVMap[K cmp.Ordered, T any](keyColumn string) func(file fs.File, config map[K]T) error {
+	return func(file fs.File, config map[K]T) error {
+		return saveDelimitedMap(file, ',', keyColumn, config)
+	}
+}
+
+// LoadTSVMap returns a load function that loads a tab-separated file into a
+// map of structs of type T. It works exactly like LoadCSVMap with a tab as the
+// field delimiter.
+func LoadTSVMap[K cmp.Ordered, T any](keyColumn string) func(file fs.File) (map[K]T, error) {
+	return func(file fs.File) (map[K]T, error) {
+		return loadDelimitedMap[K, T](file, '\t', keyColumn)
+	}
+}
+
+// SaveTSVMap returns a save function that writes a map of structs of type T to
+// the file as tab-separated values. It is the write counterpart to LoadTSVMap
+// and works exactly like SaveCSVMap with a tab as the field delimiter.
+func SaveTSVMap[K cmp.Ordered, T any](keyColumn string) func(file fs.File, config map[K]T) error {
+	return func(file fs.File, config map[K]T) error {
+		return saveDelimitedMap(file, '\t', keyColumn, config)
+	}
+}
+
+// csvColumn maps a column name to the field index path of a struct field.
+type csvColumn struct {
+	name  string
+	index []int
+}
+
+// csvColumns returns the columns of the struct type t in field order,
+// including fields promoted from embedded structs.
+func csvColumns(t reflect.Type) ([]csvColumn, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("CSV row type must be a struct or a pointer to a struct, got %s", t)
+	}
+	var (
+		columns []csvColumn
+		skipped [][]int // Indexes of embedded structs whose fields are skipped
+	)
+	for _, field := range reflect.VisibleFields(t) {
+		if slices.ContainsFunc(skipped, func(index []int) bool { return hasIndexPrefix(field.Index, index) }) {
+			continue
+		}
+		name := field.Tag.Get("csv")
+		embedded := field.Anonymous && isStructOrStructPointer(field.Type)
+		if name == "-" || embedded && !field.IsExported() && field.Type.Kind() == reflect.Pointer {
+			// Like encoding/json, skip the fields of ignored embedded structs
+			// and of embedded pointers to unexported structs,
+			// which can't be allocated
+			if embedded {
+				skipped = append(skipped, field.Index)
+			}
+			continue
+		}
+		if !field.IsExported() || embedded {
+			continue // Embedded structs are columns of their fields, even if tagged
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, csvColumn{name: name, index: field.Index})
+	}
+	return columns, nil
+}
+
+// hasIndexPrefix reports whether the field index is nested in the field
+// with the index prefix.
+func hasIndexPrefix(index, prefix []int) bool {
+	return len(index) > len(prefix) && slices.Equal(index[:len(prefix)], prefix)
+}
+
+// isStructOrStructPointer reports whether t is a struct or a pointer to a struct.
+func isStructOrStructPointer(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// csvRowType returns the struct type of rows of type T, dereferencing a
+// pointer to a struct.
+func csvRowType[T any]() reflect.Type {
+	t := reflect.TypeFor[T]()
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
+
+// newCSVRow returns a pointer to a new zero row of type T and the addressable
+// struct value to set its fields on.
+func newCSVRow[T any]() (row *T, structValue reflect.Value) {
+	row = new(T)
+	v := reflect.ValueOf(row).Elem()
+	if v.Kind() == reflect.Pointer {
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+	return row, v
+}
+
+// csvRowStruct returns the struct value of row, or an invalid reflect.Value
+// for a nil pointer row.
+func csvRowStruct[T any](row T) reflect.Value {
+	v := reflect.ValueOf(&row).Elem()
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// readDelimited parses the file content into a header and data records.
+// An empty file returns a nil header without error.
+func readDelimited(file fs.File, comma rune) (header []string, records [][]string, err error) {
+	data, err := readContent(context.Background(), file)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = comma
+	header, err = r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	records, err = r.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	return header, records, nil
+}
+
+// setCSVFields sets the fields of the struct value v from record using the
+// column index of each field in header. lineNum is used for error messages.
+func setCSVFields(v reflect.Value, columns []csvColumn, header map[string]int, record []string, lineNum int) error {
+	for _, col := range columns {
+		i, ok := header[col.name]
+		if !ok {
+			continue
+		}
+		// Allocates nil embedded struct pointers
+		field, err := fieldByIndexAlloc(v, col.index)
+		if err != nil {
+			return fmt.Errorf("line %d, column %q: %w", lineNum, col.name, err)
+		}
+		err = parseText(field, record[i])
+		if err != nil {
+			return fmt.Errorf("line %d, column %q: %w", lineNum, col.name, err)
+		}
+	}
+	return nil
+}
+
+// csvHeaderIndex maps each column name of header to its position.
+func csvHeaderIndex(header []string) map[string]int {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+	return index
+}
+
+func loadDelimited[T any](file fs.File, comma rune) ([]T, error) {
+	columns, err := csvColumns(csvRowType[T]())
+	if err != nil {
+		return nil, err
+	}
+	header, records, err := readDelimited(file, comma)
+	if err != nil {
+		return nil, err
+	}
+	headerIndex := csvHeaderIndex(header)
+	rows := make([]T, 0, len(records))
+	for i, record := range records {
+		row, v := newCSVRow[T]()
+		err = setCSVFields(v, columns, headerIndex, record, i+2)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, *row)
+	}
+	return rows, nil
+}
+
+func loadDelimitedMap[K cmp.Ordered, T any](file fs.File, comma rune, keyColumn string) (map[K]T, error) {
+	columns, err := csvColumns(csvRowType[T]())
+	if err != nil {
+		return nil, err
+	}
+	header, records, err := readDelimited(file, comma)
+	if err != nil {
+		return nil, err
+	}
+	headerIndex := csvHeaderIndex(header)
+	keyIndex, ok := headerIndex[keyColumn]
+	if !ok && header != nil {
+		return nil, fmt.Errorf("key column %q not found in header", keyColumn)
+	}
+	rows := make(map[K]T, len(records))
+	for i, record := range records {
+		lineNum := i + 2
+		var key K
+		err = parseText(reflect.ValueOf(&key).Elem(), record[keyIndex])
+		if err != nil {
+			return nil, fmt.Errorf("line %d, key column %q: %w", lineNum, keyColumn, err)
+		}
+		if _, exists := rows[key]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key %v", lineNum, key)
+		}
+		row, v := newCSVRow[T]()
+		err = setCSVFields(v, columns, headerIndex, record, lineNum)
+		if err != nil {
+			return nil, err
+		}
+		rows[key] = *row
+	}
+	return rows, nil
+}
+
+// appendCSVFields appends the formatted field values of row to record.
+// A nil pointer row is written as empty cells.
+func appendCSVFields[T any](record []string, columns []csvColumn, row T) ([]string, error) {
+	v := csvRowStruct(row)
+	for _, col := range columns {
+		if !v.IsValid() {
+			record = append(record, "")
+			continue
+		}
+		field, err := v.FieldByIndexErr(col.index)
+		if err != nil {
+			record = append(record, "") // Nil embedded struct pointer
+			continue
+		}
+		str, err := formatText(field)
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %w", col.name, err)
+		}
+		record = append(record, str)
+	}
+	return record, nil
+}
+
+// writeDelimited writes the records as delimited text to the file.
+func writeDelimited(file fs.File, comma rune, records [][]string) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = comma
+	err := w.WriteAll(records)
+	if err != nil {
+		return err
+	}
+	return file.WriteAll(buf.Bytes())
+}
+
+func columnNames(columns []csvColumn) []string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.name
+	}
+	return names
+}
+
+func saveDelimited[T any](file fs.File, comma rune, config []T) error {
+	columns, err := csvColumns(csvRowType[T]())
+	if err != nil {
+		return err
+	}
+	records := make([][]string, 0, len(config)+1)
+	records = append(records, columnNames(columns))
+	for _, row := range config {
+		record, err := appendCSVFields(make([]string, 0, len(columns)), columns, row)
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+	}
+	return writeDelimited(file, comma, records)
+}
+
+func saveDelimitedMap[K cmp.Ordered, T any](file fs.File, comma rune, keyColumn string, config map[K]T) error {
+	columns, err := csvColumns(csvRowType[T]())
+	if err != nil {
+		return err
+	}
+	// The map key is authoritative for the key column
+	columns = slices.DeleteFunc(columns, func(col csvColumn) bool { return col.name == keyColumn })
+
+	records := make([][]string, 0, len(config)+1)
+	records = append(records, append([]string{keyColumn}, columnNames(columns)...))
+	for _, key := range slices.Sorted(maps.Keys(config)) {
+		keyStr, err := formatText(reflect.ValueOf(key))
+		if err != nil {
+			return fmt.Errorf("key column %q: %w", keyColumn, err)
+		}
+		record, err := appendCSVFields(append(make([]string, 0, len(columns)+1), keyStr), columns, config[key])
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+	}
+	return writeDelimited(file, comma, records)
+}
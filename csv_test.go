@@ -0,0 +1,303 @@
+package dynconfig
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type priceRow struct {
+	SKU      string        `csv:"sku"`
+	Price    float64       `csv:"price"`
+	Qty      int           `csv:"qty"`
+	Active   bool          `csv:"active"`
+	TTL      time.Duration `csv:"ttl"`
+	Since    time.Time     `csv:"since"`
+	Discount *float64      `csv:"discount"`
+	Note     string        // Untagged, column name "Note"
+	Internal string        `csv:"-"`
+}
+
+func TestLoadCSV(t *testing.T) {
+	file := memFile(t, "prices.csv", "sku,price,qty,active,ttl,since,discount,Note,unknown\n"+
+		"A-1,9.99,3,true,1h30m,2026-01-02T03:04:05Z,0.5,\"hello, world\",x\n"+
+		"B-2,19.5,0,false,,,,,y\n")
+
+	got, err := LoadCSV[priceRow](file)
+	if err != nil {
+		t.Fatalf("LoadCSV: %s", err)
+	}
+	half := 0.5
+	want := []priceRow{
+		{
+			SKU:      "A-1",
+			Price:    9.99,
+			Qty:      3,
+			Active:   true,
+			TTL:      90 * time.Minute,
+			Since:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			Discount: &half,
+			Note:     "hello, world",
+		},
+		{SKU: "B-2", Price: 19.5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadCSV_Pointer(t *testing.T) {
+	file := memFile(t, "prices.csv", "sku,qty\nA-1,3\n")
+
+	got, err := LoadCSV[*priceRow](file)
+	if err != nil {
+		t.Fatalf("LoadCSV: %s", err)
+	}
+	if len(got) != 1 || got[0] == nil || got[0].SKU != "A-1" || got[0].Qty != 3 {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestLoadCSV_Empty(t *testing.T) {
+	file := memFile(t, "prices.csv", "")
+
+	got, err := LoadCSV[priceRow](file)
+	if err != nil {
+		t.Fatalf("LoadCSV: %s", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %+v, want empty", got)
+	}
+}
+
+func TestLoadCSV_InvalidValue(t *testing.T) {
+	file := memFile(t, "prices.csv", "sku,qty\nA-1,three\n")
+
+	_, err := LoadCSV[priceRow](file)
+	if err == nil {
+		t.Error("expected error for invalid int cell")
+	}
+}
+
+func TestLoadCSV_NotStruct(t *testing.T) {
+	file := memFile(t, "values.csv", "a\n1\n")
+
+	_, err := LoadCSV[int](file)
+	if err == nil {
+		t.Error("expected error for non-struct row type")
+	}
+}
+
+func TestSaveLoadCSVRoundTrip(t *testing.T) {
+	file := memFile(t, "prices.csv", "")
+
+	half := 0.5
+	want := []priceRow{
+		{
+			SKU:      "A-1",
+			Price:    9.99,
+			Qty:      -3,
+			Active:   true,
+			TTL:      90 * time.Minute,
+			Since:    time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC),
+			Discount: &half,
+			Note:     "quoted \"note\",\nwith newline",
+		},
+		{SKU: "B-2"},
+	}
+	if err := SaveCSV(file, want); err != nil {
+		t.Fatalf("SaveCSV: %s", err)
+	}
+	got, err := LoadCSV[priceRow](file)
+	if err != nil {
+		t.Fatalf("LoadCSV: %s", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestSaveCSV_Header(t *testing.T) {
+	file := memFile(t, "prices.csv", "old")
+
+	if err := SaveCSV(file, []priceRow{{SKU: "A-1", Qty: 3}}); err != nil {
+		t.Fatalf("SaveCSV: %s", err)
+	}
+	want := "sku,price,qty,active,ttl,since,discount,Note\n" +
+		"A-1,0,3,false,0s,0001-01-01T00:00:00Z,,\n"
+	if got := readBack(t, file); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCSV_Embedded(t *testing.T) {
+	type Base struct {
+		ID int `csv:"id"`
+	}
+	type Audit struct {
+		Author string `csv:"author"`
+	}
+	type row struct {
+		*Base
+		Audit
+		Name string `csv:"name"`
+	}
+	file := memFile(t, "rows.csv", "id,author,name\n1,ann,x\n")
+
+	got, err := LoadCSV[row](file)
+	if err != nil {
+		t.Fatalf("LoadCSV: %s", err)
+	}
+	want := []row{{Base: &Base{ID: 1}, Audit: Audit{Author: "ann"}, Name: "x"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// A nil embedded pointer is written as empty cells
+	err = SaveCSV(file, append(want, row{Name: "y"}))
+	if err != nil {
+		t.Fatalf("SaveCSV: %s", err)
+	}
+	if got, want := readBack(t, file), "id,author,name\n1,ann,x\n,,y\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCSV_EmbeddedTagged(t *testing.T) {
+	type Base struct {
+		ID int `csv:"id"`
+	}
+	type Ignored struct {
+		Secret string `csv:"secret"`
+	}
+	type row struct {
+		Base    `csv:"base"`
+		Ignored `csv:"-"`
+		Name    string `csv:"name"`
+	}
+	file := memFile(t, "rows.csv", "id,name\n1,x\n")
+
+	got, err := LoadCSV[row](file)
+	if err != nil {
+		t.Fatalf("LoadCSV: %s", err)
+	}
+	want := []row{{Base: Base{ID: 1}, Name: "x"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	err = SaveCSV(file, want)
+	if err != nil {
+		t.Fatalf("SaveCSV: %s", err)
+	}
+	if got, want := readBack(t, file), "id,name\n1,x\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSaveLoadTSVRoundTrip(t *testing.T) {
+	file := memFile(t, "prices.tsv", "")
+
+	want := []priceRow{{SKU: "A-1", Price: 1.5, Note: "tab\tinside"}, {SKU: "B-2", Qty: 7}}
+	if err := SaveTSV(file, want); err != nil {
+		t.Fatalf("SaveTSV: %s", err)
+	}
+	if got := readBack(t, file); got[:4] != "sku\t" {
+		t.Errorf("content %q does not start with a tab-separated header", got)
+	}
+	got, err := LoadTSV[priceRow](file)
+	if err != nil {
+		t.Fatalf("LoadTSV: %s", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+type tenantRow struct {
+	Name  string `csv:"name"`
+	Quota int    `csv:"quota"`
+}
+
+func TestLoadCSVMap(t *testing.T) {
+	file := memFile(t, "tenants.csv", "name,id,quota\nacme,1,100\nglobex,2,250\n")
+
+	got, err := LoadCSVMap[int, tenantRow]("id")(file)
+	if err != nil {
+		t.Fatalf("LoadCSVMap: %s", err)
+	}
+	want := map[int]tenantRow{1: {Name: "acme", Quota: 100}, 2: {Name: "globex", Quota: 250}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadCSVMap_DuplicateKey(t *testing.T) {
+	file := memFile(t, "tenants.csv", "id,name\n1,acme\n1,globex\n")
+
+	_, err := LoadCSVMap[int, tenantRow]("id")(file)
+	if err == nil {
+		t.Error("expected error for duplicate key")
+	}
+}
+
+func TestLoadCSVMap_MissingKeyColumn(t *testing.T) {
+	file := memFile(t, "tenants.csv", "name\nacme\n")
+
+	_, err := LoadCSVMap[int, tenantRow]("id")(file)
+	if err == nil {
+		t.Error("expected error for missing key column")
+	}
+}
+
+// TestSaveCSVMap verifies the key column is written first, rows are sorted by
+// key, and a field mapped to the key column is taken from the map key.
+func TestSaveCSVMap(t *testing.T) {
+	type keyedTenant struct {
+		ID   string `csv:"id"`
+		Name string `csv:"name"`
+	}
+	file := memFile(t, "tenants.csv", "old")
+
+	config := map[string]keyedTenant{
+		"globex": {ID: "ignored", Name: "Globex"},
+		"acme":   {Name: "Acme"},
+	}
+	if err := SaveCSVMap[string, keyedTenant]("id")(file, config); err != nil {
+		t.Fatalf("SaveCSVMap: %s", err)
+	}
+	want := "id,name\nacme,Acme\nglobex,Globex\n"
+	if got := readBack(t, file); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	loaded, err := LoadCSVMap[string, keyedTenant]("id")(file)
+	if err != nil {
+		t.Fatalf("LoadCSVMap: %s", err)
+	}
+	if loaded["globex"].ID != "globex" {
+		t.Errorf("key field = %q, want %q (filled from the key column)", loaded["globex"].ID, "globex")
+	}
+}
+
+func TestCSVMap_Mutate(t *testing.T) {
+	file := writeTempJSON(t, "tenants.tsv", "id\tname\tquota\n1\tacme\t100\n")
+
+	loader, err := LoadAndWatch(file, LoadTSVMap[int, tenantRow]("id"), SaveTSVMap[int, tenantRow]("id"), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadAndWatch: %s", err)
+	}
+	defer loader.Unwatch() //nolint:errcheck
+
+	err = loader.Mutate(false, func(tenants map[int]tenantRow) (map[int]tenantRow, error) {
+		tenants[2] = tenantRow{Name: "globex", Quota: 250}
+		return tenants, nil
+	})
+	if err != nil {
+		t.Fatalf("Mutate: %s", err)
+	}
+	want := "id\tname\tquota\n1\tacme\t100\n2\tglobex\t250\n"
+	if got := readBack(t, file); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,311 @@
+package dynconfig
+
+import (
+	"bytes"
+	"cmp"
+	"encoding"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// ChangeKind is the kind of a Change.
+type ChangeKind int
+
+const (
+	// ChangeModified means the value at the path changed.
+	ChangeModified ChangeKind = iota
+	// ChangeAdded means the value at the path was added to a slice,
+	// array or map, or a nil pointer or interface got a value.
+	ChangeAdded
+	// ChangeRemoved means the value at the path was removed from a slice
+	// or map, or a pointer or interface became nil.
+	ChangeRemoved
+)
+
+// String returns "modified", "added" or "removed".
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeModified:
+		return "modified"
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Change describes a value that differs between two configurations,
+// as returned by Diff.
+type Change struct {
+	// Path is the dot separated path of the value like "Database.Pool.Max",
+	// with [index] for slice and array elements and [key] for map entries
+	// like "Servers[1].Host" or "Limits[api]".
+	// It is empty if the configurations differ as a whole.
+	Path string
+	Kind ChangeKind
+	// Old is the previous value, nil for ChangeAdded.
+	Old any
+	// New is the new value, nil for ChangeRemoved.
+	New any
+	// Secret is true if the value is a field tagged as secret or part of
+	// one. Old and New are then masked like by Redacted.
+	// Old and New values containing secret fields, like an added pointer
+	// to a struct with a secret field, have those fields masked.
+	Secret bool
+}
+
+// String returns the change in a form for logging like
+// "Database.Pool.Max: 10 → 20", "Limits[api]: added 5" or
+// "Password: secret changed".
+func (c Change) String() string {
+	path := c.Path
+	if path == "" {
+		path = "."
+	}
+	switch {
+	case c.Secret && c.Kind == ChangeModified:
+		return path + ": secret changed"
+	case c.Secret:
+		return path + ": secret " + c.Kind.String()
+	case c.Kind == ChangeAdded:
+		return fmt.Sprintf("%s: added %v", path, c.New)
+	case c.Kind == ChangeRemoved:
+		return fmt.Sprintf("%s: removed %v", path, c.Old)
+	}
+	return fmt.Sprintf("%s: %v → %v", path, c.Old, c.New)
+}
+
+// Diff returns the changes from old to new, comparing exported struct
+// fields, pointers, interfaces, slice and array elements and map entries
+// recursively, in field order with sorted map keys.
+// It returns nil if there are no differences.
+//
+// Values of types implementing encoding.TextMarshaler, like time.Time,
+// are compared by their text and structs without exported fields with
+// reflect.DeepEqual. Unexported fields are ignored, except for the exported
+// fields of embedded structs, and so are functions and channels.
+//
+// Changes of fields tagged with secret:"true" or dynconfig:"secret"
+// are reported as a single Change of the field with Secret true
+// and the values masked like by Redacted. The secret fields of added,
+// removed and replaced values containing them are masked the same way.
+//
+// Example:
+//
+//	for _, change := range dynconfig.Diff(oldConfig, newConfig) {
+//	    log.Print(change) // Database.Pool.Max: 10 → 20
+//	}
+func Diff[T any](old, new T) []Change {
+	d := differ{visited: make(map[visitedPair]bool)}
+	d.diff("", reflect.ValueOf(&old).Elem(), reflect.ValueOf(&new).Elem())
+	return d.changes
+}
+
+// visitedPair identifies a pair of compared pointers.
+type visitedPair struct {
+	typ  reflect.Type
+	a, b uintptr
+}
+
+// differ implements Diff, remembering the compared pointers to handle cycles.
+type differ struct {
+	changes []Change
+	visited map[visitedPair]bool
+}
+
+// add adds a Change of the values old and new, which are invalid for added
+// or removed values, with their secret fields masked.
+func (d *differ) add(path string, kind ChangeKind, old, new reflect.Value) {
+	change := Change{Path: path, Kind: kind}
+	if old.IsValid() {
+		change.Old = redactedInterface(old)
+	}
+	if new.IsValid() {
+		change.New = redactedInterface(new)
+	}
+	d.changes = append(d.changes, change)
+}
+
+// redactedInterface returns v as interface value, copied with its secret
+// fields masked like by Redacted if it contains any.
+func redactedInterface(v reflect.Value) any {
+	r := redactor{copies: make(map[pointerKey]reflect.Value)}
+	return r.value(v).Interface()
+}
+
+func (d *differ) diff(path string, a, b reflect.Value) {
+	// A nil and a non-nil pointer or interface are added or removed below
+	if a.Type().Implements(textMarshalerType) && !oneNil(a, b) {
+		if !textEqual(a, b) {
+			d.add(path, ChangeModified, a, b)
+		}
+		return
+	}
+	switch a.Kind() {
+	case reflect.Pointer:
+		switch {
+		case a.IsNil() && b.IsNil():
+		case a.IsNil():
+			d.add(path, ChangeAdded, reflect.Value{}, b.Elem())
+		case b.IsNil():
+			d.add(path, ChangeRemoved, a.Elem(), reflect.Value{})
+		default:
+			pair := visitedPair{a.Type(), a.Pointer(), b.Pointer()}
+			if a.Pointer() == b.Pointer() || d.visited[pair] {
+				return
+			}
+			d.visited[pair] = true
+			d.diff(path, a.Elem(), b.Elem())
+		}
+
+	case reflect.Interface:
+		switch {
+		case a.IsNil() && b.IsNil():
+		case a.IsNil():
+			d.add(path, ChangeAdded, reflect.Value{}, b.Elem())
+		case b.IsNil():
+			d.add(path, ChangeRemoved, a.Elem(), reflect.Value{})
+		case a.Elem().Type() != b.Elem().Type():
+			d.add(path, ChangeModified, a.Elem(), b.Elem())
+		default:
+			d.diff(path, a.Elem(), b.Elem())
+		}
+
+	case reflect.Struct:
+		if !hasExportedFields(a.Type()) {
+			if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+				d.add(path, ChangeModified, a, b)
+			}
+			return
+		}
+		d.fields(path, a, b)
+
+	case reflect.Slice, reflect.Array:
+		for i := range min(a.Len(), b.Len()) {
+			d.diff(fmt.Sprintf("%s[%d]", path, i), a.Index(i), b.Index(i))
+		}
+		for i := b.Len(); i < a.Len(); i++ {
+			d.add(fmt.Sprintf("%s[%d]", path, i), ChangeRemoved, a.Index(i), reflect.Value{})
+		}
+		for i := a.Len(); i < b.Len(); i++ {
+			d.add(fmt.Sprintf("%s[%d]", path, i), ChangeAdded, reflect.Value{}, b.Index(i))
+		}
+
+	case reflect.Map:
+		for _, key := range sortedMapKeys(a, b) {
+			keyPath := fmt.Sprintf("%s[%v]", path, key)
+			oldValue, newValue := a.MapIndex(key), b.MapIndex(key)
+			switch {
+			case !newValue.IsValid():
+				d.add(keyPath, ChangeRemoved, oldValue, reflect.Value{})
+			case !oldValue.IsValid():
+				d.add(keyPath, ChangeAdded, reflect.Value{}, newValue)
+			default:
+				d.diff(keyPath, oldValue, newValue)
+			}
+		}
+
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer, reflect.Invalid:
+		// Not configuration values
+
+	default:
+		if !a.Equal(b) {
+			d.add(path, ChangeModified, a, b)
+		}
+	}
+}
+
+// fields compares the fields of the structs a and b.
+func (d *differ) fields(path string, a, b reflect.Value) {
+	for i := range a.NumField() {
+		field := a.Type().Field(i)
+		switch {
+		case isEmbeddedUnexported(field):
+			// Promoted fields without the type name, like encoding/json
+			d.fields(path, a.Field(i), b.Field(i))
+		case !field.IsExported():
+			continue
+		case isSecret(field):
+			d.diffSecret(joinFieldPath(path, field.Name), a.Field(i), b.Field(i))
+		default:
+			d.diff(joinFieldPath(path, field.Name), a.Field(i), b.Field(i))
+		}
+	}
+}
+
+// diffSecret adds a single masked Change for the secret field values a and b
+// if they differ.
+func (d *differ) diffSecret(path string, a, b reflect.Value) {
+	inner := differ{visited: make(map[visitedPair]bool)}
+	inner.diff(path, a, b)
+	if len(inner.changes) == 0 {
+		return
+	}
+	kind := ChangeModified
+	if len(inner.changes) == 1 && inner.changes[0].Path == path {
+		kind = inner.changes[0].Kind
+	}
+	change := Change{Path: path, Kind: kind, Secret: true}
+	if kind != ChangeAdded {
+		change.Old = redactedValue(a).Interface()
+	}
+	if kind != ChangeRemoved {
+		change.New = redactedValue(b).Interface()
+	}
+	d.changes = append(d.changes, change)
+}
+
+// oneNil reports whether exactly one of the values a and b of the same type
+// is a nil pointer or interface.
+func oneNil(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return a.IsNil() != b.IsNil()
+	}
+	return false
+}
+
+// textEqual reports whether a and b, implementing encoding.TextMarshaler,
+// marshal to the same text. Both may be nil.
+func textEqual(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Pointer || a.Kind() == reflect.Interface {
+		if a.IsNil() && b.IsNil() {
+			return true
+		}
+	}
+	aText, aErr := a.Interface().(encoding.TextMarshaler).MarshalText()
+	bText, bErr := b.Interface().(encoding.TextMarshaler).MarshalText()
+	if aErr != nil || bErr != nil {
+		return reflect.DeepEqual(a.Interface(), b.Interface())
+	}
+	return bytes.Equal(aText, bText)
+}
+
+// hasExportedFields reports whether the struct type t has exported
+// fields, including those of embedded structs of unexported types.
+func hasExportedFields(t reflect.Type) bool {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.IsExported() || isEmbeddedUnexported(field) && hasExportedFields(field.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedMapKeys returns the keys of the maps a and b
+// sorted by their formatted value.
+func sortedMapKeys(a, b reflect.Value) []reflect.Value {
+	keys := a.MapKeys()
+	for _, key := range b.MapKeys() {
+		if !a.MapIndex(key).IsValid() {
+			keys = append(keys, key)
+		}
+	}
+	slices.SortFunc(keys, func(x, y reflect.Value) int {
+		return cmp.Compare(fmt.Sprint(x), fmt.Sprint(y))
+	})
+	return keys
+}
@@ -0,0 +1,231 @@
+package dynconfig
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type diffPool struct {
+	Max int
+	Min int
+}
+
+type diffDatabase struct {
+	Host     string
+	Password string `secret:"true"`
+	Pool     *diffPool
+}
+
+type diffConfig struct {
+	DB       diffDatabase
+	Hosts    []string
+	Limits   map[string]int
+	Timeout  time.Duration
+	Started  time.Time
+	Extra    any
+	APIKey   *string `dynconfig:"secret"`
+	OnChange func()
+	internal int
+}
+
+func TestDiff(t *testing.T) {
+	started := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	key := "key"
+	old := diffConfig{
+		DB:      diffDatabase{Host: "db", Password: "old", Pool: &diffPool{Max: 10, Min: 1}},
+		Hosts:   []string{"a", "b", "c"},
+		Limits:  map[string]int{"api": 5, "web": 10},
+		Timeout: time.Second,
+		Started: started,
+		Extra:   1,
+	}
+	new := diffConfig{
+		DB:       diffDatabase{Host: "db", Password: "new", Pool: &diffPool{Max: 20, Min: 1}},
+		Hosts:    []string{"a", "x"},
+		Limits:   map[string]int{"web": 10, "admin": 1},
+		Timeout:  time.Second,
+		Started:  started.In(time.FixedZone("CET", 3600)), // Same instant, different text
+		Extra:    "one",
+		APIKey:   &key,
+		OnChange: func() {},
+		internal: 1,
+	}
+
+	got := Diff(old, new)
+	masked := RedactedString
+	want := []Change{
+		{Path: "DB.Password", Kind: ChangeModified, Old: RedactedString, New: RedactedString, Secret: true},
+		{Path: "DB.Pool.Max", Kind: ChangeModified, Old: 10, New: 20},
+		{Path: "Hosts[1]", Kind: ChangeModified, Old: "b", New: "x"},
+		{Path: "Hosts[2]", Kind: ChangeRemoved, Old: "c"},
+		{Path: "Limits[admin]", Kind: ChangeAdded, New: 1},
+		{Path: "Limits[api]", Kind: ChangeRemoved, Old: 5},
+		{Path: "Started", Kind: ChangeModified, Old: old.Started, New: new.Started},
+		{Path: "Extra", Kind: ChangeModified, Old: 1, New: "one"},
+		{Path: "APIKey", Kind: ChangeAdded, New: &masked, Secret: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff =\n%v\nwant\n%v", got, want)
+	}
+
+	if changes := Diff(old, old); changes != nil {
+		t.Errorf("Diff of equal configs = %v, want nil", changes)
+	}
+	if changes := Diff(&old, &old); changes != nil {
+		t.Errorf("Diff of the same pointer = %v, want nil", changes)
+	}
+	if changes := Diff[any](1, 2); len(changes) != 1 || changes[0].Path != "" {
+		t.Errorf("Diff[any](1, 2) = %v, want one change of the whole value", changes)
+	}
+}
+
+func TestChange_String(t *testing.T) {
+	tests := []struct {
+		change Change
+		want   string
+	}{
+		{Change{Path: "DB.Pool.Max", Kind: ChangeModified, Old: 10, New: 20}, "DB.Pool.Max: 10 → 20"},
+		{Change{Path: "Limits[api]", Kind: ChangeAdded, New: 5}, "Limits[api]: added 5"},
+		{Change{Path: "Hosts[2]", Kind: ChangeRemoved, Old: "c"}, "Hosts[2]: removed c"},
+		{Change{Path: "Password", Kind: ChangeModified, Old: RedactedString, New: RedactedString, Secret: true}, "Password: secret changed"},
+		{Change{Path: "APIKey", Kind: ChangeAdded, Secret: true}, "APIKey: secret added"},
+		{Change{Kind: ChangeModified, Old: 1, New: 2}, ".: 1 → 2"},
+	}
+	for _, tt := range tests {
+		if got := tt.change.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestDiff_Cycle(t *testing.T) {
+	type ring struct {
+		Value int
+		Next  *ring
+	}
+	a := &ring{Value: 1}
+	a.Next = a
+	b := &ring{Value: 2}
+	b.Next = b
+
+	got := Diff(a, b)
+	paths := make([]string, len(got))
+	for i, change := range got {
+		paths[i] = change.Path
+	}
+	if !slices.Equal(paths, []string{"Value"}) {
+		t.Errorf("Diff of cyclic values = %v, want one change of Value", got)
+	}
+}
+
+// TestDiff_AddedSecrets verifies that added and removed values containing
+// secret fields are reported with those fields masked.
+func TestDiff_AddedSecrets(t *testing.T) {
+	type config struct {
+		DB      *diffDatabase
+		Replica []diffDatabase
+		Shards  map[string]diffDatabase
+		Extra   any
+	}
+	db := diffDatabase{Host: "db", Password: "hunter2"}
+	masked := diffDatabase{Host: "db", Password: RedactedString}
+	full := config{
+		DB:      &db,
+		Replica: []diffDatabase{db},
+		Shards:  map[string]diffDatabase{"eu": db},
+		Extra:   db,
+	}
+	empty := config{Replica: []diffDatabase{}, Shards: map[string]diffDatabase{}}
+
+	tests := []struct {
+		name     string
+		old, new config
+		want     []Change
+	}{
+		{
+			name: "added",
+			old:  empty,
+			new:  full,
+			want: []Change{
+				{Path: "DB", Kind: ChangeAdded, New: masked},
+				{Path: "Replica[0]", Kind: ChangeAdded, New: masked},
+				{Path: "Shards[eu]", Kind: ChangeAdded, New: masked},
+				{Path: "Extra", Kind: ChangeAdded, New: masked},
+			},
+		},
+		{
+			name: "removed",
+			old:  full,
+			new:  empty,
+			want: []Change{
+				{Path: "DB", Kind: ChangeRemoved, Old: masked},
+				{Path: "Replica[0]", Kind: ChangeRemoved, Old: masked},
+				{Path: "Shards[eu]", Kind: ChangeRemoved, Old: masked},
+				{Path: "Extra", Kind: ChangeRemoved, Old: masked},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Diff(tt.old, tt.new)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff =\n%v\nwant\n%v", got, tt.want)
+			}
+			for _, change := range got {
+				if strings.Contains(change.String(), "hunter2") {
+					t.Errorf("change %q contains the secret", change)
+				}
+			}
+		})
+	}
+	if db.Password != "hunter2" {
+		t.Error("Diff modified the compared value")
+	}
+}
+
+func TestDiff_NilTextMarshaler(t *testing.T) {
+	type config struct {
+		Expires *time.Time
+	}
+	expires := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	same := expires
+	later := expires.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		old, new config
+		want     []Change
+	}{
+		{name: "nil", old: config{}, new: config{}},
+		{name: "equal", old: config{&expires}, new: config{&same}},
+		{
+			name: "added",
+			old:  config{},
+			new:  config{&expires},
+			want: []Change{{Path: "Expires", Kind: ChangeAdded, New: expires}},
+		},
+		{
+			name: "removed",
+			old:  config{&expires},
+			new:  config{},
+			want: []Change{{Path: "Expires", Kind: ChangeRemoved, Old: expires}},
+		},
+		{
+			name: "modified",
+			old:  config{&expires},
+			new:  config{&later},
+			want: []Change{{Path: "Expires", Kind: ChangeModified, Old: &expires, New: &later}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Diff(tt.old, tt.new)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
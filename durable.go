@@ -0,0 +1,57 @@
+package dynconfig
+
+import (
+	"errors"
+	"os"
+)
+
+// WithDurableWrites enables or disables durable writes on the atomic write
+// path of Set, Mutate and Rollback for local files. Durable writes are
+// enabled by default.
+//
+// With durable writes the temporary file is flushed to stable storage with
+// fsync before it is renamed over the configuration file, and the directory
+// is flushed after the rename. Without the first fsync a power loss can
+// persist the rename but not the data, leaving an empty or truncated
+// configuration file; without the second the rename itself can be lost.
+//
+// Disable durable writes only for files where losing the last writes after
+// a crash is acceptable and fsync latency matters, like in tests.
+func WithDurableWrites(enabled bool) Option {
+	return func(o *loaderOptions) {
+		o.nonDurableWrites = !enabled
+	}
+}
+
+// fileOps are the file system operations of the atomic write path that
+// tests replace to inject faults.
+type fileOps struct {
+	rename   func(oldPath, newPath string) error
+	syncFile func(path string) error
+	syncDir  func(dir string) error
+}
+
+// osFileOps are the fileOps of the operating system.
+var osFileOps = &fileOps{
+	rename:   os.Rename,
+	syncFile: syncPath,
+	syncDir:  syncPath,
+}
+
+// syncPath opens the file or directory at path and flushes it to stable
+// storage with fsync.
+func syncPath(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	return errors.Join(f.Sync(), f.Close())
+}
+
+// fileOps returns the file system operations used by the Loader.
+func (l *Loader[T]) fileOps() *fileOps {
+	if l.opts.fileOps != nil {
+		return l.opts.fileOps
+	}
+	return osFileOps
+}
@@ -0,0 +1,130 @@
+package dynconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// faultyFileOps returns fileOps that record every call as "op:base name"
+// and fail the operation named fail.
+func faultyFileOps(calls *[]string, fail string) *fileOps {
+	record := func(op, path string) error {
+		*calls = append(*calls, op+":"+filepath.Base(path))
+		if op == fail {
+			return errors.New("injected " + op + " fault")
+		}
+		return nil
+	}
+	return &fileOps{
+		rename: func(oldPath, newPath string) error {
+			if err := record("rename", newPath); err != nil {
+				return err
+			}
+			return os.Rename(oldPath, newPath)
+		},
+		syncFile: func(path string) error {
+			if err := record("syncFile", strings.Split(filepath.Base(path), ".tmp-")[0]); err != nil {
+				return err
+			}
+			return syncPath(path)
+		},
+		syncDir: func(dir string) error {
+			if err := record("syncDir", "dir"); err != nil {
+				return err
+			}
+			return syncPath(dir)
+		},
+	}
+}
+
+func TestDurableWrites_Order(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value":1}`)
+
+	var calls []string
+	loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+	loader.opts.fileOps = faultyFileOps(&calls, "")
+	if err := loader.Set(counter{Value: 2}); err != nil {
+		t.Fatalf("Set: %s", err)
+	}
+	want := []string{"syncFile:.counter.json", "rename:counter.json", "syncDir:dir"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("got calls %v, want %v", calls, want)
+	}
+}
+
+func TestDurableWrites_Disabled(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value":1}`)
+
+	var calls []string
+	loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil, WithDurableWrites(false))
+	loader.opts.fileOps = faultyFileOps(&calls, "")
+	if err := loader.Set(counter{Value: 2}); err != nil {
+		t.Fatalf("Set: %s", err)
+	}
+	if want := []string{"rename:counter.json"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("got calls %v, want %v", calls, want)
+	}
+}
+
+// TestDurableWrites_SyncFileFault verifies a failing fsync of the temporary
+// file aborts before the rename, leaving the original file intact.
+func TestDurableWrites_SyncFileFault(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value":1}`)
+
+	var calls []string
+	loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+	loader.opts.fileOps = faultyFileOps(&calls, "syncFile")
+	err := loader.Mutate(true, func(c counter) (counter, error) {
+		c.Value++
+		return c, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "injected syncFile fault") {
+		t.Fatalf("Mutate error = %v, want injected syncFile fault", err)
+	}
+	if got := readValue(t, file.LocalPath()); got != 1 {
+		t.Errorf("file value = %d, want unchanged 1", got)
+	}
+	if want := []string{"syncFile:.counter.json"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("got calls %v, want %v", calls, want)
+	}
+	assertOnlyFile(t, filepath.Dir(file.LocalPath()), "counter.json")
+}
+
+// TestDurableWrites_SyncDirFault verifies a failing fsync of the directory
+// is reported although the rename already replaced the file.
+func TestDurableWrites_SyncDirFault(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value":1}`)
+
+	var calls []string
+	loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+	loader.opts.fileOps = faultyFileOps(&calls, "syncDir")
+	err := loader.Set(counter{Value: 2})
+	if err == nil || !strings.Contains(err.Error(), "injected syncDir fault") {
+		t.Fatalf("Set error = %v, want injected syncDir fault", err)
+	}
+	if got := readValue(t, file.LocalPath()); got != 2 {
+		t.Errorf("file value = %d, want renamed 2", got)
+	}
+	assertOnlyFile(t, filepath.Dir(file.LocalPath()), "counter.json")
+}
+
+// TestDurableWrites_RenameFault verifies a failing rename removes the
+// temporary file and leaves the original file intact.
+func TestDurableWrites_RenameFault(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value":1}`)
+
+	var calls []string
+	loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+	loader.opts.fileOps = faultyFileOps(&calls, "rename")
+	if err := loader.Set(counter{Value: 2}); err == nil {
+		t.Fatal("expected injected rename fault")
+	}
+	if got := readValue(t, file.LocalPath()); got != 1 {
+		t.Errorf("file value = %d, want unchanged 1", got)
+	}
+	assertOnlyFile(t, filepath.Dir(file.LocalPath()), "counter.json")
+}
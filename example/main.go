@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"log/slog"
 
 	"github.com/ungerik/go-dynconfig"
 	"github.com/ungerik/go-dynconfig/loadenv"
@@ -11,6 +12,8 @@ type Config struct {
 	A string
 	B bool
 	C int
+	// Password is masked by dynconfig.Dump
+	Password string `secret:"true"`
 }
 
 // Load config of type *Config from "config.json" and watch for changes.
@@ -23,6 +26,8 @@ var config = dynconfig.MustLoadAndWatch(
 	nil, // onLoad
 	nil, // onError: nil means that errors will panic
 	nil, // onInvalidate
+	// Log loads, invalidations and errors instead of using callbacks
+	dynconfig.WithLogger(slog.Default()),
 )
 
 // Use the callbacks to log what's happening
@@ -48,8 +53,13 @@ var emailBlackist = dynconfig.MustLoadAndWatch(
 )
 
 func main() {
+	// Stop watching and release the loaders when main returns
+	defer config.Close()
+	defer emailBlackist.Close()
+
 	// Get will always return the latest configuration
 	// independent of any errors during loading
-	log.Printf("Loaded config: %#v", config.Get())
+	// Dump masks secret fields, unlike %#v
+	log.Printf("Loaded config: %s", dynconfig.Dump(config.Get()))
 	log.Printf("Loaded blacklist: %s", emailBlackist.Get())
 }
@@ -0,0 +1,205 @@
+package dynconfig
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ungerik/go-fs"
+)
+
+// BindFlags defines a command line flag in flagSet for every field of the
+// configuration type T with a flag struct tag, and returns a load function
+// wrapping load that applies the flags set on the command line after every
+// load.
+//
+// Because the flags are applied again by every reload, hot reloads keep them
+// and the precedence stays flag > environment > file when load is for
+// example loadenv.LoadEnvJSON. Only flags that were explicitly set on the
+// command line override values; flags that were not passed leave the loaded
+// value alone, so flags have no defaults of their own.
+//
+// Struct Tag Format:
+//   - flag:"name" - Defines the flag -name for the field
+//   - usage:"text" - Optional usage text shown by -help
+//
+// Fields of nested structs and non-nil pointers to structs are bound too.
+// Supported field types are strings, bools (usable as -name without value),
+// signed and unsigned integers, floats, time.Duration, types implementing
+// encoding.TextUnmarshaler (like time.Time), and pointers to those.
+// Invalid flag values are rejected by flagSet.Parse.
+//
+// If flagSet is nil, flag.CommandLine is used. BindFlags must be called
+// before the flags are parsed. Defining a flag that already exists in
+// flagSet panics like flag.Var does.
+//
+// Applied flags are reported as SourceFlag with ReportSources.
+//
+// Example:
+//
+//	type Config struct {
+//	    Host  string `json:"host"  env:"HOST"  flag:"host"  usage:"Host to listen on"`
+//	    Port  int    `json:"port"  env:"PORT"  flag:"port"  usage:"Port to listen on"`
+//	    Debug bool   `json:"debug"              flag:"debug" usage:"Enable debug logging"`
+//	}
+//
+//	load, err := dynconfig.BindFlags(nil, loadenv.LoadEnvJSON[*Config])
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	flag.Parse()
+//
+//	// ./server -port 9090 keeps port 9090 across hot reloads
+//	loader := dynconfig.MustLoadAndWatch("config.json", load, nil, nil, nil, nil)
+func BindFlags[T any](flagSet *flag.FlagSet, load func(fs.File) (T, error)) (func(fs.File) (T, error), error) {
+	if load == nil {
+		return nil, errors.New("load function must not be nil")
+	}
+	if flagSet == nil {
+		flagSet = flag.CommandLine
+	}
+	t := reflect.TypeFor[T]()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("BindFlags() configuration type must be a struct or a pointer to a struct, got %s", reflect.TypeFor[T]())
+	}
+	fields, err := flagFields(t, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("BindFlags() %w", err)
+	}
+	byName := make(map[string]*flagField, len(fields))
+	for _, field := range fields {
+		if byName[field.name] != nil {
+			return nil, fmt.Errorf("BindFlags() flag -%s used by %s and %s", field.name, byName[field.name].path, field.path)
+		}
+		byName[field.name] = field
+	}
+	for _, field := range fields {
+		flagSet.Var(field, field.name, field.usage)
+	}
+
+	return func(file fs.File) (T, error) {
+		config, err := load(file)
+		if err != nil {
+			return config, err
+		}
+		sources := make(Sources)
+		var errs []error
+		flagSet.Visit(func(f *flag.Flag) {
+			field := byName[f.Name]
+			if field == nil || f.Value != flag.Value(field) {
+				return // Not bound by this call
+			}
+			v, e := fieldByIndexAlloc(reflect.ValueOf(&config).Elem(), field.index)
+			if e == nil {
+				e = parseText(v, field.text)
+			}
+			if e != nil {
+				errs = append(errs, fmt.Errorf("flag -%s: %w", field.name, e))
+				return
+			}
+			sources[field.path] = Source{Kind: SourceFlag, Name: "-" + field.name}
+		})
+		if len(errs) > 0 {
+			return *new(T), errors.Join(errs...)
+		}
+		ReportSources(file, sources)
+		return config, nil
+	}, nil
+}
+
+// flagField is a configuration field bound to a command line flag.
+// It implements flag.Value, storing the text passed on the command line.
+type flagField struct {
+	name  string
+	usage string
+	path  string
+	index []int
+	typ   reflect.Type
+	text  string
+}
+
+// String implements flag.Value.
+func (f *flagField) String() string {
+	if f == nil {
+		return "" // flag.isZeroValue calls String on a zero value
+	}
+	return f.text
+}
+
+// Set implements flag.Value by validating str against the field type.
+func (f *flagField) Set(str string) error {
+	err := parseText(reflect.New(f.typ).Elem(), str)
+	if err != nil {
+		return err
+	}
+	f.text = str
+	return nil
+}
+
+// IsBoolFlag makes bool fields usable as -name without value.
+func (f *flagField) IsBoolFlag() bool {
+	t := f.typ
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool && !reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// flagFields returns the fields of the struct type t with a flag tag,
+// recursing into nested structs and pointers to structs.
+func flagFields(t reflect.Type, index []int, path string) ([]*flagField, error) {
+	var fields []*flagField
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fieldIndex := append(index[:len(index):len(index)], i)
+		fieldPath := joinFieldPath(path, sf.Name)
+		name, tagged := sf.Tag.Lookup("flag")
+		if name == "-" {
+			continue
+		}
+		if !tagged {
+			if nested := nestedFlagStruct(sf.Type); nested != nil {
+				nestedFields, err := flagFields(nested, fieldIndex, fieldPath)
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, nestedFields...)
+			}
+			continue
+		}
+		if name == "" || strings.HasPrefix(name, "-") || strings.Contains(name, "=") {
+			return nil, fmt.Errorf("invalid flag name %q of field %s", name, fieldPath)
+		}
+		if !textTypeSupported(sf.Type) {
+			return nil, fmt.Errorf("unsupported type %s of field %s for flag -%s", sf.Type, fieldPath, name)
+		}
+		fields = append(fields, &flagField{
+			name:  name,
+			usage: sf.Tag.Get("usage"),
+			path:  fieldPath,
+			index: fieldIndex,
+			typ:   sf.Type,
+		})
+	}
+	return fields, nil
+}
+
+// nestedFlagStruct returns the struct type to recurse into if t is a struct
+// or a pointer to a struct that is not parsed from a single text value.
+func nestedFlagStruct(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || textTypeSupported(t) {
+		return nil
+	}
+	return t
+}
@@ -0,0 +1,104 @@
+package dynconfig
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type flagConfig struct {
+	Host    string        `json:"host" flag:"host" usage:"Host to listen on"`
+	Port    int           `json:"port" flag:"port"`
+	Debug   bool          `json:"debug" flag:"debug"`
+	Timeout time.Duration `json:"timeout" flag:"timeout"`
+	Limit   *float64      `json:"limit" flag:"limit"`
+	DB      *struct {
+		Name string `json:"name" flag:"db-name"`
+	} `json:"db"`
+	Ignored string `json:"ignored" flag:"-"`
+}
+
+func newTestFlagSet() *flag.FlagSet {
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	return flagSet
+}
+
+func TestBindFlags(t *testing.T) {
+	file := writeTempJSON(t, "config.json", `{"host":"from-file","port":8080,"timeout":1000000000}`)
+
+	flagSet := newTestFlagSet()
+	load, err := BindFlags(flagSet, LoadJSON[*flagConfig])
+	if err != nil {
+		t.Fatalf("BindFlags: %s", err)
+	}
+	err = flagSet.Parse([]string{"-port", "9090", "-debug", "-limit=0.5", "-db-name", "app"})
+	if err != nil {
+		t.Fatalf("Parse: %s", err)
+	}
+
+	loader := NewLoader(file, load, nil, nil, nil, nil)
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	if cfg.Host != "from-file" || cfg.Port != 9090 || !cfg.Debug || cfg.Timeout != time.Second {
+		t.Errorf("got %+v", cfg)
+	}
+	if cfg.Limit == nil || *cfg.Limit != 0.5 || cfg.DB == nil || cfg.DB.Name != "app" {
+		t.Errorf("got Limit=%v DB=%+v", cfg.Limit, cfg.DB)
+	}
+	want := Sources{
+		"Port":    {Kind: SourceFlag, Name: "-port"},
+		"Debug":   {Kind: SourceFlag, Name: "-debug"},
+		"Limit":   {Kind: SourceFlag, Name: "-limit"},
+		"DB.Name": {Kind: SourceFlag, Name: "-db-name"},
+	}
+	if got := loader.Sources(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sources() = %v, want %v", got, want)
+	}
+
+	// Flags must survive reloading changed file content
+	if err := file.WriteAllString(`{"host":"changed","port":1}`); err != nil {
+		t.Fatalf("WriteAllString: %s", err)
+	}
+	if err := loader.Reload(); err != nil {
+		t.Fatalf("Reload: %s", err)
+	}
+	if cfg := loader.Get(); cfg.Host != "changed" || cfg.Port != 9090 {
+		t.Errorf("after reload got Host=%q Port=%d, want changed and 9090", cfg.Host, cfg.Port)
+	}
+}
+
+func TestBindFlags_InvalidValue(t *testing.T) {
+	flagSet := newTestFlagSet()
+	if _, err := BindFlags(flagSet, LoadJSON[flagConfig]); err != nil {
+		t.Fatalf("BindFlags: %s", err)
+	}
+	if err := flagSet.Parse([]string{"-port", "many"}); err == nil {
+		t.Error("expected error for invalid int flag value")
+	}
+}
+
+func TestBindFlags_Errors(t *testing.T) {
+	type unsupported struct {
+		Values []string `flag:"values"`
+	}
+	if _, err := BindFlags(newTestFlagSet(), LoadJSON[unsupported]); err == nil {
+		t.Error("expected error for unsupported field type")
+	}
+
+	type duplicate struct {
+		A string `flag:"name"`
+		B string `flag:"name"`
+	}
+	if _, err := BindFlags(newTestFlagSet(), LoadJSON[duplicate]); err == nil {
+		t.Error("expected error for duplicate flag name")
+	}
+
+	if _, err := BindFlags(newTestFlagSet(), LoadJSON[int]); err == nil {
+		t.Error("expected error for non-struct configuration type")
+	}
+}
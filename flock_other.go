@@ -20,6 +20,12 @@ func lockFileExclusive(*os.File) error {
 	return nil
 }
 
+// tryLockFileExclusive is a no-op on platforms without file locking support.
+// It is never called because Set guards the lock with fsLockSupported.
+func tryLockFileExclusive(*os.File) (bool, error) {
+	return true, nil
+}
+
 // unlockFile is a no-op on platforms without file locking support.
 func unlockFile(*os.File) error {
 	return nil
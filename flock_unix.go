@@ -3,6 +3,7 @@
 package dynconfig
 
 import (
+	"errors"
 	"os"
 
 	"golang.org/x/sys/unix"
@@ -21,6 +22,17 @@ func lockFileExclusive(f *os.File) error {
 	return unix.Flock(int(f.Fd()), unix.LOCK_EX)
 }
 
+// tryLockFileExclusive tries to acquire an exclusive (write) advisory lock
+// on the open file using flock(2) without blocking. It returns false without
+// an error if another open file description holds a lock on the file.
+func tryLockFileExclusive(f *os.File) (bool, error) {
+	err := unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if errors.Is(err, unix.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 // unlockFile releases an advisory lock previously acquired with lockFileExclusive.
 func unlockFile(f *os.File) error {
 	return unix.Flock(int(f.Fd()), unix.LOCK_UN)
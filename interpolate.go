@@ -0,0 +1,328 @@
+package dynconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/ungerik/go-fs"
+)
+
+// Interpolate wraps a load function of any format so that references inside
+// the string values of the loaded configuration are expanded:
+//
+//   - ${NAME} is replaced by the environment variable NAME,
+//     or an empty string if it is not set.
+//   - ${NAME:-default} is replaced by the environment variable NAME,
+//     or by default if it is not set or empty. The default may contain
+//     references itself.
+//   - ${file:path} is replaced by the content of the file at path with
+//     surrounding whitespace trimmed. A relative path is resolved against
+//     the directory of the configuration file.
+//   - ${config:Field.Path} is replaced by the value of another field of the
+//     configuration, given as dot separated Go field names. A referenced
+//     string field is expanded first, numbers, bools, durations and
+//     encoding.TextMarshaler values are formatted as text.
+//     Reference cycles are an error.
+//   - $${ is replaced by a literal ${ and a $ not followed by { is kept.
+//
+// Expansion covers string fields of nested structs, pointers, slices, arrays,
+// maps and interface values, like those of a map[string]any. Values that came
+// from environment variables or files are not expanded again.
+//
+// Referenced files are reported with ReportDependencies, so a watching
+// Loader reloads when one of them changes.
+//
+// The loaded configuration holds the expanded values, the references are
+// not kept. Saving it would overwrite the references in the file with their
+// values, writing secrets from the environment or from secret files in
+// plaintext into the configuration file. A Loader using Interpolate
+// therefore refuses to save with Set and Mutate and returns ErrInterpolated.
+//
+// Example:
+//
+//	// config.json:
+//	// {
+//	//   "host": "${DB_HOST:-localhost}",
+//	//   "password": "${file:/run/secrets/db_password}",
+//	//   "url": "postgres://${config:Host}/app"
+//	// }
+//	type Config struct {
+//	    Host     string `json:"host"`
+//	    Password string `json:"password"`
+//	    URL      string `json:"url"`
+//	}
+//
+//	loader := dynconfig.MustLoadAndWatch(
+//	    "config.json",
+//	    dynconfig.Interpolate(dynconfig.LoadJSON[Config]),
+//	    nil, nil, nil, nil,
+//	)
+func Interpolate[T any](load func(fs.File) (T, error)) func(fs.File) (T, error) {
+	return func(file fs.File) (T, error) {
+		config, err := load(file)
+		if err != nil {
+			return config, err
+		}
+		ip := &interpolator{
+			file:     file,
+			root:     reflect.ValueOf(&config).Elem(),
+			expanded: make(map[any]bool),
+			active:   make(map[any]bool),
+		}
+		err = ip.visit("", ip.root)
+		if err != nil {
+			return *new(T), fmt.Errorf("interpolate %s error: %w", file, err)
+		}
+		reportInterpolated(file)
+		return config, nil
+	}
+}
+
+// ErrInterpolated is returned wrapped by Set and Mutate of a Loader whose
+// configuration was loaded with Interpolate, because saving it would
+// replace the ${...} references in the file with their expanded values.
+var ErrInterpolated = errors.New("can't save interpolated configuration")
+
+// reportInterpolated tells the Loader loading file that the loaded
+// configuration holds expanded references.
+func reportInterpolated(file fs.File) {
+	loadReportsMtx.Lock()
+	defer loadReportsMtx.Unlock()
+
+	if report := currentLoadReport(file); report != nil {
+		report.interpolated = true
+	}
+}
+
+// interpolator expands the references in the string values of root.
+type interpolator struct {
+	file fs.File
+	root reflect.Value
+	// expanded and active hold pointers to string values that have been
+	// expanded or are being expanded, so values referenced by other
+	// fields are expanded exactly once and cycles are detected.
+	expanded map[any]bool
+	active   map[any]bool
+}
+
+// visit expands all string values reachable from the settable value v.
+func (ip *interpolator) visit(path string, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.String:
+		return ip.expandValue(path, v)
+
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return ip.visit(path, v.Elem())
+
+	case reflect.Struct:
+		var errs []error
+		t := v.Type()
+		for i := range t.NumField() {
+			if field := v.Field(i); field.CanSet() {
+				errs = append(errs, ip.visit(joinFieldPath(path, t.Field(i).Name), field))
+			}
+		}
+		return errors.Join(errs...)
+
+	case reflect.Slice, reflect.Array:
+		var errs []error
+		for i := range v.Len() {
+			errs = append(errs, ip.visit(fmt.Sprintf("%s[%d]", path, i), v.Index(i)))
+		}
+		return errors.Join(errs...)
+
+	case reflect.Map:
+		// Map values are not addressable, so expand a copy and store it back
+		var errs []error
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			errs = append(errs, ip.visit(fmt.Sprintf("%s[%v]", path, iter.Key()), elem))
+			v.SetMapIndex(iter.Key(), elem)
+		}
+		return errors.Join(errs...)
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		// The dynamic value is not addressable, so expand a copy and store it back
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		err := ip.visit(path, elem)
+		v.Set(elem)
+		return err
+	}
+	return nil
+}
+
+// expandValue expands the references in the addressable string value v
+// unless it has been expanded already.
+func (ip *interpolator) expandValue(path string, v reflect.Value) error {
+	key := v.Addr().Interface()
+	if ip.expanded[key] {
+		return nil
+	}
+	if ip.active[key] {
+		return fmt.Errorf("%s: reference cycle", path)
+	}
+	ip.active[key] = true
+	expanded, err := ip.expand(v.String())
+	delete(ip.active, key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	v.SetString(expanded)
+	ip.expanded[key] = true
+	return nil
+}
+
+// expand returns str with all references replaced.
+func (ip *interpolator) expand(str string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(str, '$')
+		if i < 0 {
+			b.WriteString(str)
+			return b.String(), nil
+		}
+		b.WriteString(str[:i])
+		str = str[i:]
+		switch {
+		case strings.HasPrefix(str, "$${"):
+			b.WriteString("${")
+			str = str[3:]
+		case strings.HasPrefix(str, "${"):
+			end := closingBrace(str)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated reference %q", str)
+			}
+			value, err := ip.resolve(str[2:end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			str = str[end+1:]
+		default:
+			b.WriteByte('$')
+			str = str[1:]
+		}
+	}
+}
+
+// closingBrace returns the index of the brace closing the reference
+// that str starts with, taking nested references into account,
+// or -1 if there is none.
+func closingBrace(str string) int {
+	depth := 0
+	for i := 1; i < len(str); i++ {
+		switch str[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// resolve returns the value of a single reference without ${ and }.
+func (ip *interpolator) resolve(ref string) (string, error) {
+	if path, ok := strings.CutPrefix(ref, "file:"); ok {
+		return ip.readFile(path)
+	}
+	if path, ok := strings.CutPrefix(ref, "config:"); ok {
+		return ip.lookup(path)
+	}
+	name, def, hasDefault := strings.Cut(ref, ":-")
+	if name == "" {
+		return "", fmt.Errorf("empty variable name in reference ${%s}", ref)
+	}
+	value := os.Getenv(name)
+	if value == "" && hasDefault {
+		return ip.expand(def)
+	}
+	return value, nil
+}
+
+// readFile returns the trimmed content of the file at path, relative to the
+// directory of the configuration file, and reports it as dependency.
+func (ip *interpolator) readFile(path string) (string, error) {
+	path, err := ip.expand(path)
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return "", errors.New("empty file path in reference ${file:}")
+	}
+	ref := fs.File(path)
+	if !filepath.IsAbs(path) && !strings.Contains(path, "://") {
+		ref = ip.file.Dir().Join(path)
+	}
+	ReportDependencies(ip.file, ref)
+	content, err := ref.ReadAllString()
+	if err != nil {
+		return "", fmt.Errorf("read referenced file error: %w", err)
+	}
+	return strings.TrimSpace(content), nil
+}
+
+// lookup returns the text of the configuration field at the
+// dot separated path of Go field names.
+func (ip *interpolator) lookup(path string) (string, error) {
+	v := ip.root
+	for name := range strings.SplitSeq(path, ".") {
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return "", fmt.Errorf("config reference %q: nil value before %s", path, name)
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return "", fmt.Errorf("config reference %q: %s is not a struct field", path, name)
+		}
+		field, ok := v.Type().FieldByName(name)
+		if !ok || !field.IsExported() {
+			return "", fmt.Errorf("config reference %q: no exported field %s", path, name)
+		}
+		var err error
+		v, err = v.FieldByIndexErr(field.Index)
+		if err != nil {
+			return "", fmt.Errorf("config reference %q: %w", path, err)
+		}
+	}
+	if !v.CanInterface() {
+		return "", fmt.Errorf("config reference %q: field not accessible", path)
+	}
+	if v.Kind() == reflect.String && v.CanSet() {
+		err := ip.expandValue(path, v)
+		if err != nil {
+			return "", err
+		}
+		return v.String(), nil
+	}
+	text, err := formatText(v)
+	if err != nil {
+		return "", fmt.Errorf("config reference %q: %w", path, err)
+	}
+	return text, nil
+}
+
+// joinFieldPath appends name to the dot separated field path.
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
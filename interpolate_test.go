@@ -0,0 +1,207 @@
+package dynconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ungerik/go-fs"
+)
+
+type interpolateConfig struct {
+	Host     string            `json:"host"`
+	Port     int               `json:"port"`
+	Password string            `json:"password"`
+	URL      string            `json:"url"`
+	Literal  string            `json:"literal"`
+	Timeout  time.Duration     `json:"timeout"`
+	Hosts    []string          `json:"hosts"`
+	Labels   map[string]string `json:"labels"`
+	Extra    map[string]any    `json:"extra"`
+	Nested   *struct {
+		Name host `json:"name"`
+	} `json:"nested"`
+}
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("DYNCONFIG_TEST_HOST", "db.example.com")
+	t.Setenv("DYNCONFIG_TEST_EMPTY", "")
+	t.Setenv("DYNCONFIG_TEST_REFERENCE", "${NOT_EXPANDED}")
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "password"), []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	file := fs.File(filepath.Join(dir, "config.json"))
+	err := file.WriteAllString(`{
+		"host": "${DYNCONFIG_TEST_HOST}",
+		"port": 5432,
+		"password": "${file:password}",
+		"url": "postgres://${config:Host}:${config:Port}/app?timeout=${config:Timeout}",
+		"literal": "$${DYNCONFIG_TEST_HOST} costs $5",
+		"timeout": 1500000000,
+		"hosts": ["${DYNCONFIG_TEST_EMPTY:-${DYNCONFIG_TEST_UNSET:-fallback}}", "${DYNCONFIG_TEST_REFERENCE}"],
+		"labels": {"db": "${config:Nested.Name}"},
+		"extra": {"list": ["${DYNCONFIG_TEST_HOST}"], "number": 1},
+		"nested": {"name": "${config:Host}"}
+	}`)
+	if err != nil {
+		t.Fatalf("WriteAllString: %s", err)
+	}
+
+	loader := NewLoader(file, Interpolate(LoadJSON[interpolateConfig]), nil, nil, nil, nil)
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	if cfg.Host != "db.example.com" || cfg.Password != "s3cret" {
+		t.Errorf("got Host=%q Password=%q", cfg.Host, cfg.Password)
+	}
+	if want := "postgres://db.example.com:5432/app?timeout=1.5s"; cfg.URL != want {
+		t.Errorf("got URL=%q, want %q", cfg.URL, want)
+	}
+	if want := "${DYNCONFIG_TEST_HOST} costs $5"; cfg.Literal != want {
+		t.Errorf("got Literal=%q, want %q", cfg.Literal, want)
+	}
+	if want := []string{"fallback", "${NOT_EXPANDED}"}; !reflect.DeepEqual(cfg.Hosts, want) {
+		t.Errorf("got Hosts=%q, want %q", cfg.Hosts, want)
+	}
+	if cfg.Labels["db"] != "db.example.com" || cfg.Nested.Name != "db.example.com" {
+		t.Errorf("got Labels=%v Nested.Name=%q", cfg.Labels, cfg.Nested.Name)
+	}
+	if want := []any{"db.example.com"}; !reflect.DeepEqual(cfg.Extra["list"], want) {
+		t.Errorf("got Extra[list]=%v, want %v", cfg.Extra["list"], want)
+	}
+	if want := []fs.File{fs.File(filepath.Join(dir, "password"))}; !reflect.DeepEqual(loader.Dependencies(), want) {
+		t.Errorf("Dependencies() = %v, want %v", loader.Dependencies(), want)
+	}
+}
+
+func TestInterpolate_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"cycle", `{"host":"${config:URL}","url":"${config:Host}"}`, "reference cycle"},
+		{"unknown field", `{"host":"${config:Missing}"}`, "no exported field Missing"},
+		{"nil pointer", `{"host":"${config:Nested.Name}"}`, "nil value"},
+		{"missing file", `{"host":"${file:missing.txt}"}`, "read referenced file"},
+		{"unterminated", `{"host":"${DYNCONFIG_TEST_HOST"}`, "unterminated reference"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := writeTempJSON(t, "config.json", tt.content)
+			_, err := Interpolate(LoadJSON[interpolateConfig])(file)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestInterpolate_WatchesReferencedFiles verifies a watching Loader reloads
+// when a file referenced with ${file:...} changes.
+func TestInterpolate_WatchesReferencedFiles(t *testing.T) {
+	secret := writeTempJSON(t, "password", "old")
+	file := writeTempJSON(t, "config.json", `{"password":"${file:`+filepath.ToSlash(string(secret))+`}"}`)
+
+	loader, err := LoadAndWatch(file, Interpolate(LoadJSON[interpolateConfig]), nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadAndWatch: %s", err)
+	}
+	defer loader.Unwatch() //nolint:errcheck
+
+	if got := loader.Get().Password; got != "old" {
+		t.Fatalf("got %q, want old", got)
+	}
+	if err := secret.WriteAllString("new"); err != nil {
+		t.Fatalf("WriteAllString: %s", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for loader.Get().Password != "new" {
+		if time.Now().After(deadline) {
+			t.Fatal("referenced file change did not reload the configuration")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestInterpolate_Save verifies that the expanded values of an interpolated
+// configuration are not saved over the references in the file.
+func TestInterpolate_Save(t *testing.T) {
+	t.Setenv("DYNCONFIG_TEST_PASSWORD", "s3cret")
+	const content = `{"port":5432,"password":"${DYNCONFIG_TEST_PASSWORD}"}`
+	file := writeTempJSON(t, "config.json", content)
+	loader := NewLoader(file, Interpolate(LoadJSON[interpolateConfig]), SaveJSON[interpolateConfig](), nil, nil, nil)
+
+	mutate := func(c interpolateConfig) (interpolateConfig, error) {
+		c.Port++
+		return c, nil
+	}
+	// Without a loaded configuration Mutate reads the file itself
+	err := loader.Mutate(false, mutate)
+	if !errors.Is(err, ErrInterpolated) {
+		t.Errorf("Mutate before Load: got %v, want ErrInterpolated", err)
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	if cfg.Password != "s3cret" {
+		t.Fatalf("Password = %q, want s3cret", cfg.Password)
+	}
+	for _, reload := range []bool{false, true} {
+		err = loader.Mutate(reload, mutate)
+		if !errors.Is(err, ErrInterpolated) {
+			t.Errorf("Mutate(%t): got %v, want ErrInterpolated", reload, err)
+		}
+	}
+	err = loader.Set(cfg)
+	if !errors.Is(err, ErrInterpolated) {
+		t.Errorf("Set: got %v, want ErrInterpolated", err)
+	}
+	if got := readBack(t, file); got != content {
+		t.Errorf("file = %s, want unchanged %s", got, content)
+	}
+	if got := loader.Get(); got.Port != 5432 || got.Password != "s3cret" {
+		t.Errorf("Get = %+v, want the loaded configuration", got)
+	}
+}
+
+// TestInterpolate_SaveWatched verifies that a watching Loader using
+// Interpolate with a save function doesn't write a secret read from a
+// referenced file into the configuration file, and watches that file.
+func TestInterpolate_SaveWatched(t *testing.T) {
+	secret := writeTempJSON(t, "password", "hunter2")
+	content := `{"port":5432,"password":"${file:` + filepath.ToSlash(string(secret)) + `}"}`
+	file := writeTempJSON(t, "config.json", content)
+
+	loader, err := LoadAndWatch(file, Interpolate(LoadJSON[interpolateConfig]), SaveJSON[interpolateConfig](), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadAndWatch: %s", err)
+	}
+	defer loader.Unwatch() //nolint:errcheck
+
+	if got := loader.Dependencies(); !reflect.DeepEqual(got, []fs.File{secret}) {
+		t.Errorf("Dependencies() = %v, want [%s]", got, secret)
+	}
+	err = loader.Mutate(true, func(c interpolateConfig) (interpolateConfig, error) {
+		c.Port++
+		return c, nil
+	})
+	if !errors.Is(err, ErrInterpolated) {
+		t.Errorf("Mutate: got %v, want ErrInterpolated", err)
+	}
+	err = loader.Set(loader.Get())
+	if !errors.Is(err, ErrInterpolated) {
+		t.Errorf("Set: got %v, want ErrInterpolated", err)
+	}
+	if got := readBack(t, file); got != content {
+		t.Errorf("file = %s, want unchanged %s", got, content)
+	}
+}
@@ -2,6 +2,8 @@ package dynconfig
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 
 	"github.com/ungerik/go-fs"
 )
@@ -41,10 +43,21 @@ import (
 //	    nil, nil, nil,
 //	)
 func LoadJSON[T any](file fs.File) (config T, err error) {
-	err = file.ReadJSON(context.Background(), &config)
+	return LoadJSONContext[T](context.Background(), file)
+}
+
+// LoadJSONContext is the context-aware variant of LoadJSON for
+// NewLoaderContext and LoadAndWatchContext. ctx is passed to the go-fs file
+// system, so reading a remote file can be canceled.
+func LoadJSONContext[T any](ctx context.Context, file fs.File) (config T, err error) {
+	data, err := readContent(ctx, file)
 	if err != nil {
 		return *new(T), err
 	}
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return *new(T), fmt.Errorf("%w because: %w", fs.ErrUnmarshalJSON, err)
+	}
 	return config, nil
 }
 
@@ -79,7 +92,15 @@ func LoadJSON[T any](file fs.File) (config T, err error) {
 //	    return cfg, nil
 //	})
 func SaveJSON[T any](indent ...string) func(file fs.File, config T) error {
-	return func(file fs.File, config T) error {
-		return file.WriteJSON(context.Background(), config, indent...)
+	return BackgroundSave(SaveJSONContext[T](indent...))
+}
+
+// SaveJSONContext is the context-aware variant of SaveJSON for
+// NewLoaderContext and LoadAndWatchContext. The context passed to
+// MutateContext, SetContext or SetIfVersionContext is passed to the go-fs
+// file system, so writing a remote file can be canceled.
+func SaveJSONContext[T any](indent ...string) SaveContextFunc[T] {
+	return func(ctx context.Context, file fs.File, config T) error {
+		return file.WriteJSON(ctx, config, indent...)
 	}
 }
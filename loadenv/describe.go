@@ -0,0 +1,170 @@
+package loadenv
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvVar describes an environment variable read by ParseEnv.
+type EnvVar struct {
+	// Name of the environment variable including all prefixes.
+	Name string
+	// FileName is the NAME_FILE variable with the path of a secret file
+	// to read the value from if Name is not set.
+	FileName string
+	// Path is the dot separated path of the struct field, like "Database.Host".
+	Path string
+	// Type is the Go type of the field, like "int" or "time.Duration".
+	Type string
+	// Default is the value of the envDefault tag.
+	Default string
+	// HasDefault is true if the field has an envDefault tag.
+	HasDefault bool
+	// Required is true if the env tag has the required or notEmpty option.
+	Required bool
+	// Description is the value of the optional usage tag.
+	Description string
+}
+
+// Describe returns the environment variables ParseEnv reads for a
+// configuration of type T, a struct or pointer to a struct,
+// in field order including nested structs.
+//
+// Use it with MarkdownTable or EnvExample to generate documentation,
+// for example with go generate. A usage struct tag is used as description.
+//
+// Example:
+//
+//	//go:generate go run ./cmd/envdocs
+//
+//	// cmd/envdocs/main.go
+//	func main() {
+//	    vars := loadenv.Describe[config.Config]()
+//	    os.WriteFile("ENVIRONMENT.md", []byte(loadenv.MarkdownTable(vars)), 0o644)
+//	    os.WriteFile(".env.example", []byte(loadenv.EnvExample(vars)), 0o644)
+//	}
+func Describe[T any]() []EnvVar {
+	return DescribeWithOptions[T](EnvOptions{})
+}
+
+// DescribeWithOptions returns the environment variables ParseEnvWithOptions
+// reads with opts for a configuration of type T, a struct or pointer to a
+// struct. See Describe.
+//
+// Like ParseEnvWithOptions, it only recurses into nested structs that are
+// not nil pointers in the zero value of T, so the variables of a nested
+// struct pointer that is only set by the configuration file are not listed.
+func DescribeWithOptions[T any](opts EnvOptions) []EnvVar {
+	t := reflect.TypeFor[T]()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var vars []EnvVar
+	_ = walkEnvFields(reflect.New(t).Elem(), opts.Prefix, "", opts, func(f envField) error {
+		if f.Var == "" {
+			return nil // Only an envDefault, no variable
+		}
+		defaultValue, hasDefault := f.field.Tag.Lookup("envDefault")
+		required := false
+		for option := range strings.SplitSeq(f.Options, ",") {
+			required = required || option == "required" || option == "notEmpty"
+		}
+		vars = append(vars, EnvVar{
+			Name:        f.Var,
+			FileName:    f.Var + secretFileSuffix,
+			Path:        f.Path,
+			Type:        f.field.Type.String(),
+			Default:     defaultValue,
+			HasDefault:  hasDefault,
+			Required:    required,
+			Description: f.field.Tag.Get("usage"),
+		})
+		return nil
+	})
+	return vars
+}
+
+// MarkdownTable renders vars as a Markdown table with the columns
+// Variable, Type, Default, Required, Field and Description.
+// The Variable column also lists the NAME_FILE variable.
+func MarkdownTable(vars []EnvVar) string {
+	var b strings.Builder
+	b.WriteString("| Variable | Type | Default | Required | Field | Description |\n")
+	b.WriteString("|----------|------|---------|----------|-------|-------------|\n")
+	for _, v := range vars {
+		defaultValue := ""
+		if v.HasDefault {
+			defaultValue = markdownCode(v.Default)
+		}
+		required := ""
+		if v.Required {
+			required = "yes"
+		}
+		variable := markdownCode(v.Name)
+		if v.FileName != "" {
+			variable += ", " + markdownCode(v.FileName)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+			variable,
+			markdownCode(v.Type),
+			defaultValue,
+			required,
+			markdownCode(v.Path),
+			markdownCell(v.Description),
+		)
+	}
+	return b.String()
+}
+
+// EnvExample renders vars in the .env file format, one assignment per
+// variable set to its default, preceded by a comment with the description,
+// type, whether the variable is required, and the NAME_FILE alternative.
+func EnvExample(vars []EnvVar) string {
+	var b strings.Builder
+	for i, v := range vars {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		if v.Description != "" {
+			fmt.Fprintf(&b, "# %s\n", strings.ReplaceAll(v.Description, "\n", "\n# "))
+		}
+		fmt.Fprintf(&b, "# %s", v.Type)
+		if v.Required {
+			b.WriteString(", required")
+		}
+		if v.FileName != "" {
+			fmt.Fprintf(&b, ", or path of a file in %s", v.FileName)
+		}
+		b.WriteByte('\n')
+		fmt.Fprintf(&b, "%s=%s\n", v.Name, envFileValue(v.Default))
+	}
+	return b.String()
+}
+
+// markdownCode formats str as inline code for a Markdown table cell.
+func markdownCode(str string) string {
+	if str == "" {
+		return `""`
+	}
+	return "`" + strings.ReplaceAll(str, "|", `\|`) + "`"
+}
+
+// markdownCell escapes str for a Markdown table cell.
+func markdownCell(str string) string {
+	str = strings.ReplaceAll(str, "|", `\|`)
+	return strings.ReplaceAll(str, "\n", " ")
+}
+
+// envFileValue quotes value for a .env file if it contains characters
+// that would be interpreted otherwise.
+func envFileValue(value string) string {
+	if strings.ContainsAny(value, " \t\n\"'#$\\") {
+		return strconv.Quote(value)
+	}
+	return value
+}
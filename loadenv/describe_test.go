@@ -0,0 +1,75 @@
+package loadenv
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type describeConfig struct {
+	Port     int           `env:"PORT" envDefault:"8080" usage:"Port to listen on"`
+	APIKey   string        `env:"API_KEY,required" usage:"Key for the | API"`
+	Timeout  time.Duration `env:"TIMEOUT" envDefault:"30s"`
+	Greeting string        `env:"GREETING" envDefault:"hello world"`
+	Database struct {
+		Host string `env:"HOST,notEmpty"`
+	} `envPrefix:"DB_"`
+	Cache *struct {
+		Addr string `env:"ADDR"`
+	} `envPrefix:"CACHE_"` // Not read while nil
+	Next     *describeConfig
+	Internal string
+}
+
+func TestDescribe(t *testing.T) {
+	got := DescribeWithOptions[*describeConfig](EnvOptions{Prefix: "APP_"})
+	want := []EnvVar{
+		{Name: "APP_PORT", FileName: "APP_PORT_FILE", Path: "Port", Type: "int", Default: "8080", HasDefault: true, Description: "Port to listen on"},
+		{Name: "APP_API_KEY", FileName: "APP_API_KEY_FILE", Path: "APIKey", Type: "string", Required: true, Description: "Key for the | API"},
+		{Name: "APP_TIMEOUT", FileName: "APP_TIMEOUT_FILE", Path: "Timeout", Type: "time.Duration", Default: "30s", HasDefault: true},
+		{Name: "APP_GREETING", FileName: "APP_GREETING_FILE", Path: "Greeting", Type: "string", Default: "hello world", HasDefault: true},
+		{Name: "APP_DB_HOST", FileName: "APP_DB_HOST_FILE", Path: "Database.Host", Type: "string", Required: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	// Describe lists exactly the variables ParseEnvWithOptions reads
+	var read []string
+	_ = walkEnvFields(reflect.ValueOf(new(describeConfig)).Elem(), "APP_", "", EnvOptions{}, func(f envField) error {
+		read = append(read, f.Var)
+		return nil
+	})
+	if len(read) != len(got) {
+		t.Errorf("ParseEnvWithOptions reads %v, Describe lists %d variables", read, len(got))
+	}
+
+	if got := Describe[int](); got != nil {
+		t.Errorf("Describe[int]() = %+v, want nil", got)
+	}
+}
+
+func TestMarkdownTable(t *testing.T) {
+	got := MarkdownTable(Describe[describeConfig]())
+	want := "| Variable | Type | Default | Required | Field | Description |\n" +
+		"|----------|------|---------|----------|-------|-------------|\n" +
+		"| `PORT`, `PORT_FILE` | `int` | `8080` |  | `Port` | Port to listen on |\n" +
+		"| `API_KEY`, `API_KEY_FILE` | `string` |  | yes | `APIKey` | Key for the \\| API |\n" +
+		"| `TIMEOUT`, `TIMEOUT_FILE` | `time.Duration` | `30s` |  | `Timeout` |  |\n" +
+		"| `GREETING`, `GREETING_FILE` | `string` | `hello world` |  | `Greeting` |  |\n" +
+		"| `DB_HOST`, `DB_HOST_FILE` | `string` |  | yes | `Database.Host` |  |\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEnvExample(t *testing.T) {
+	got := EnvExample(Describe[describeConfig]()[:4])
+	want := "# Port to listen on\n# int, or path of a file in PORT_FILE\nPORT=8080\n\n" +
+		"# Key for the | API\n# string, required, or path of a file in API_KEY_FILE\nAPI_KEY=\n\n" +
+		"# time.Duration, or path of a file in TIMEOUT_FILE\nTIMEOUT=30s\n\n" +
+		"# string, or path of a file in GREETING_FILE\nGREETING=\"hello world\"\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -17,9 +17,18 @@
 package loadenv
 
 import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
 	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
 
 	env "github.com/caarlos0/env/v7"
+	"github.com/ungerik/go-dynconfig"
 )
 
 // ParseEnv is a configurable function that parses environment variables into a struct.
@@ -32,7 +41,16 @@ import (
 //   - env:"VAR_NAME" - Simple environment variable mapping
 //   - env:"VAR_NAME,required" - Required variable (error if not set)
 //   - env:"VAR_NAME" envDefault:"value" - Default value if not set
-//   - env:"VAR_NAME,expand" - Expand ${OTHER_VAR} references
+//   - env:"VAR_NAME" envExpand:"true" - Expand ${OTHER_VAR} references
+//
+// Secret Files:
+//
+// If a mapped variable like DB_PASSWORD is not set but DB_PASSWORD_FILE is,
+// the value is read from the file at that path with surrounding whitespace
+// trimmed. This is the convention for Docker and Kubernetes secrets mounted
+// as files, for example DB_PASSWORD_FILE=/run/secrets/db_password.
+// A set DB_PASSWORD always wins over DB_PASSWORD_FILE, and a secret file
+// that can't be read is an error.
 //
 // Supported Types:
 //   - All basic types: string, bool, int, int8, int16, int32, int64, uint, uint8, etc.
@@ -44,6 +62,8 @@ import (
 //   - Custom types implementing encoding.TextUnmarshaler
 //
 // This function is used internally by LoadEnvJSON and LoadEnvXML.
+// Use ParseEnvWithOptions to prefix variable names or derive them from
+// field names.
 //
 // Example usage:
 //
@@ -58,7 +78,7 @@ import (
 //	    APIKey string `env:"API_KEY,required"`
 //
 //	    // Variable expansion
-//	    LogPath string `env:"LOG_PATH,expand" envDefault:"${HOME}/logs"`
+//	    LogPath string `env:"LOG_PATH" envExpand:"true" envDefault:"${HOME}/logs"`
 //
 //	    // Comma-separated list
 //	    Hosts []string `env:"ALLOWED_HOSTS" envSeparator:","`
@@ -80,14 +100,362 @@ import (
 //	    return customEnvParser(dest)
 //	}
 //
-// The default implementation handles pointer-to-pointer dereferencing automatically,
-// so it works correctly when called with **T as well as *T.
+// The default implementation calls ParseEnvWithOptions with zero EnvOptions,
+// which passes dest to env.Parse after reading the secret files. It handles
+// pointer-to-pointer dereferencing automatically, so it works correctly when
+// called with **T as well as *T.
 var ParseEnv = func(dest any) error {
+	return ParseEnvWithOptions(dest, EnvOptions{})
+}
+
+// EnvOptions configures how ParseEnvWithOptions and the loaders built on it
+// map struct fields to environment variable names.
+//
+// The zero value maps fields exactly like ParseEnv's default implementation
+// by using env.Parse.
+type EnvOptions struct {
+	// Prefix is prepended to every environment variable name, so with
+	// Prefix "BILLING_" the tag `env:"PORT"` reads BILLING_PORT.
+	// This lets several services sharing a config type run side by side
+	// in one environment.
+	Prefix string
+
+	// DeriveNames derives the variable name of exported fields without an
+	// env tag from their field path, converting each path element from
+	// CamelCase to UPPER_SNAKE_CASE and joining them with underscores:
+	// Database.Host reads DATABASE_HOST (after Prefix).
+	//
+	// Nested structs also contribute their derived name to the variables of
+	// their fields with an explicit env tag, unless the struct field has an
+	// envPrefix tag, which is used instead.
+	DeriveNames bool
+}
+
+// ParseEnvWithOptions parses environment variables into the struct pointed to
+// by dest like ParseEnv does, with variable names mapped as configured by opts.
+//
+// It supports the same struct tags and types as ParseEnv, including nested
+// structs (with the envPrefix tag) and non-nil pointers to structs.
+// With zero opts it parses with env.Parse like ParseEnv. Otherwise it maps
+// the fields itself and parses each one with env.Parse, which differs in two
+// ways: the errors of the fields are joined with errors.Join instead of being
+// returned as env.AggregateError, and struct fields are always recursed into
+// unless their type implements encoding.TextUnmarshaler, even if they have
+// an env tag.
+//
+// Example:
+//
+//	type Config struct {
+//	    Port     int `env:"PORT"`
+//	    Database struct {
+//	        Host string
+//	        User string `env:"USER"`
+//	    }
+//	}
+//
+//	// Reads BILLING_PORT, BILLING_DATABASE_HOST and BILLING_DATABASE_USER
+//	err := loadenv.ParseEnvWithOptions(&config, loadenv.EnvOptions{
+//	    Prefix:      "BILLING_",
+//	    DeriveNames: true,
+//	})
+func ParseEnvWithOptions(dest any, opts EnvOptions) error {
+	if opts == (EnvOptions{}) {
+		return parseEnv(dest)
+	}
+	v, err := envStructValue(dest)
+	if err != nil {
+		return err
+	}
+	environ := environment()
+	return walkEnvFields(v, opts.Prefix, "", opts, func(f envField) error {
+		err := f.readSecretFile(environ)
+		if err != nil {
+			return err
+		}
+		return f.parse(environ)
+	})
+}
+
+// parseEnv parses dest with env.Parse after reading the secret files
+// of its fields into the environment passed to it.
+func parseEnv(dest any) error {
 	// Deref pointer to pointer because env.Parse
 	// only accepts pointers to structs
 	v := reflect.ValueOf(dest)
-	if v.Kind() == reflect.Pointer && v.Elem().Kind() == reflect.Pointer {
+	if v.Kind() == reflect.Pointer && !v.IsNil() && v.Elem().Kind() == reflect.Pointer {
 		dest = v.Elem().Interface()
 	}
-	return env.Parse(dest)
+	environ := environment()
+	if v, err := envStructValue(dest); err == nil {
+		err = walkEnvFields(v, "", "", EnvOptions{}, func(f envField) error {
+			return f.readSecretFile(environ)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return env.Parse(dest, env.Options{Environment: environ})
+}
+
+// ParseEnvWithSources parses environment variables into the struct pointed to
+// by dest like ParseEnvWithOptions does and returns the source of every field
+// mapped to an environment variable, keyed by its dot separated field path
+// like "Database.Host".
+//
+// A field is reported as dynconfig.SourceEnv with the variable name if the
+// variable set its value, as dynconfig.SourceDefault if its envDefault was
+// used because the variable was unset or empty, and otherwise as
+// dynconfig.SourceFile with an empty name, meaning the field kept the value
+// dest held before, typically read from a configuration file.
+//
+// Example:
+//
+//	sources, err := loadenv.ParseEnvWithSources(&config, loadenv.EnvOptions{Prefix: "APP_"})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(sources["Port"]) // env APP_PORT
+func ParseEnvWithSources(dest any, opts EnvOptions) (dynconfig.Sources, error) {
+	err := ParseEnvWithOptions(dest, opts)
+	if err != nil {
+		return nil, err
+	}
+	v, err := envStructValue(dest)
+	if err != nil {
+		return nil, err
+	}
+	return envSources(v, opts, environment()), nil
+}
+
+// envSources returns the sources of the fields of the struct v mapped to
+// environment variables as ParseEnvWithSources reports them.
+func envSources(v reflect.Value, opts EnvOptions, environ map[string]string) dynconfig.Sources {
+	sources := make(dynconfig.Sources)
+	_ = walkEnvFields(v, opts.Prefix, "", opts, func(f envField) error {
+		sources[f.Path] = f.source(environ)
+		return nil
+	})
+	return sources
+}
+
+// secretFileSuffix is appended to the name of a variable to get the name of
+// the variable holding the path of a file to read the value from instead.
+const secretFileSuffix = "_FILE"
+
+// envSecretFiles returns the paths of the secret files referenced by the
+// NAME_FILE variables of the fields of the struct v.
+func envSecretFiles(v reflect.Value, opts EnvOptions, environ map[string]string) []string {
+	var paths []string
+	_ = walkEnvFields(v, opts.Prefix, "", opts, func(f envField) error {
+		if path, ok := f.secretFile(environ); ok {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths
+}
+
+// envStructValue returns the struct value dest points to, dereferencing a
+// pointer to a pointer to a struct.
+func envStructValue(dest any) (reflect.Value, error) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() == reflect.Pointer && !v.IsNil() && v.Elem().Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("expected a pointer to a struct, got %T", dest)
+	}
+	return v.Elem(), nil
+}
+
+// environment returns the current process environment as a map.
+func environment() map[string]string {
+	environ := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			environ[k] = v
+		}
+	}
+	return environ
+}
+
+// envField is a struct field mapped to an environment variable.
+type envField struct {
+	// Path is the dot separated field path, like "Database.Host".
+	Path string
+	// Var is the environment variable name including all prefixes.
+	// It is empty for untagged fields with an envDefault when names
+	// are not derived.
+	Var string
+	// Options are the comma separated options of the env tag after the
+	// name, including the leading comma, like ",required".
+	Options string
+
+	field reflect.StructField
+	value reflect.Value
+}
+
+// envTagKeys are the struct tag keys besides env that configure parsing.
+var envTagKeys = []string{"envDefault", "envSeparator", "envKeyValSeparator", "envExpand"}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// walkEnvFields calls fn for every field of the struct v that is mapped to an
+// environment variable, recursing into nested structs. The errors of all
+// fields are joined.
+func walkEnvFields(v reflect.Value, prefix, path string, opts EnvOptions, fn func(envField) error) error {
+	var errs []error
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		fv := v.Field(i)
+		if !fv.CanSet() {
+			continue
+		}
+		fieldPath := sf.Name
+		if path != "" {
+			fieldPath = path + "." + sf.Name
+		}
+		name, options, _ := strings.Cut(sf.Tag.Get("env"), ",")
+		if options != "" {
+			options = "," + options
+		}
+
+		if nested, ok := nestedEnvStruct(fv); ok {
+			nestedPrefix := prefix
+			if envPrefix := sf.Tag.Get("envPrefix"); envPrefix != "" {
+				nestedPrefix += envPrefix
+			} else if opts.DeriveNames && !sf.Anonymous {
+				nestedPrefix += envName(sf.Name) + "_"
+			}
+			errs = append(errs, walkEnvFields(nested, nestedPrefix, fieldPath, opts, fn))
+			continue
+		}
+
+		if name == "" {
+			if opts.DeriveNames {
+				name = envName(sf.Name)
+			} else if _, hasDefault := sf.Tag.Lookup("envDefault"); !hasDefault {
+				continue // Not mapped to an environment variable
+			}
+		}
+		var fullName string
+		if name != "" {
+			fullName = prefix + name
+		}
+		errs = append(errs, fn(envField{
+			Path:    fieldPath,
+			Var:     fullName,
+			Options: options,
+			field:   sf,
+			value:   fv,
+		}))
+	}
+	return errors.Join(errs...)
+}
+
+// nestedEnvStruct returns the struct value to recurse into if v is a struct
+// or a non-nil pointer to a struct that is not parsed from a single string.
+func nestedEnvStruct(v reflect.Value) (reflect.Value, bool) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct || reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) || v.Type() == reflect.TypeFor[url.URL]() {
+		return reflect.Value{}, false
+	}
+	return v, true
+}
+
+// parse sets the field from the environment variable using
+// github.com/caarlos0/env/v7, so all its tag options and type conversions
+// apply to the mapped variable name. It does so by parsing into a temporary
+// single-field struct with the env tag rewritten to the full variable name.
+func (f *envField) parse(environ map[string]string) error {
+	tag := `env:` + strconv.Quote(f.Var+f.Options)
+	for _, key := range envTagKeys {
+		if val, ok := f.field.Tag.Lookup(key); ok {
+			tag += ` ` + key + `:` + strconv.Quote(val)
+		}
+	}
+	tmp := reflect.New(reflect.StructOf([]reflect.StructField{{
+		Name: f.field.Name,
+		Type: f.field.Type,
+		Tag:  reflect.StructTag(tag),
+	}})).Elem()
+	tmp.Field(0).Set(f.value)
+	err := env.Parse(tmp.Addr().Interface(), env.Options{Environment: environ})
+	if err != nil {
+		return err
+	}
+	f.value.Set(tmp.Field(0))
+	return nil
+}
+
+// secretFile returns the path from the NAME_FILE variable if the field's
+// variable NAME is not set.
+func (f *envField) secretFile(environ map[string]string) (path string, ok bool) {
+	if f.Var == "" {
+		return "", false
+	}
+	if _, isSet := environ[f.Var]; isSet {
+		return "", false
+	}
+	path = environ[f.Var+secretFileSuffix]
+	return path, path != ""
+}
+
+// readSecretFile sets the field's variable in environ to the trimmed
+// content of the file referenced by its NAME_FILE variable, if any.
+func (f *envField) readSecretFile(environ map[string]string) error {
+	path, ok := f.secretFile(environ)
+	if !ok {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read secret file from %s%s error: %w", f.Var, secretFileSuffix, err)
+	}
+	environ[f.Var] = strings.TrimSpace(string(data))
+	return nil
+}
+
+// source returns where the value of the field comes from after parse,
+// following the rules of github.com/caarlos0/env/v7: a set, non-empty
+// variable wins over envDefault, which wins over the existing value.
+func (f *envField) source(environ map[string]string) dynconfig.Source {
+	value, isSet := environ[f.Var]
+	_, hasDefault := f.field.Tag.Lookup("envDefault")
+	_, hasSecretFile := f.secretFile(environ)
+	switch {
+	case f.Var != "" && isSet && value != "":
+		return dynconfig.Source{Kind: dynconfig.SourceEnv, Name: f.Var}
+	case hasSecretFile:
+		return dynconfig.Source{Kind: dynconfig.SourceEnv, Name: f.Var + secretFileSuffix}
+	case hasDefault:
+		return dynconfig.Source{Kind: dynconfig.SourceDefault, Name: f.Var}
+	default:
+		return dynconfig.Source{Kind: dynconfig.SourceFile}
+	}
+}
+
+// envName converts a CamelCase field name to UPPER_SNAKE_CASE, keeping
+// acronyms together: "MaxConns" becomes "MAX_CONNS", "DBHost" becomes
+// "DB_HOST", and "APIKey" becomes "API_KEY".
+func envName(fieldName string) string {
+	runes := []rune(fieldName)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
 }
@@ -1,6 +1,16 @@
 package loadenv
 
-import "testing"
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	env "github.com/caarlos0/env/v7"
+	"github.com/ungerik/go-dynconfig"
+)
 
 func TestParseEnv_BasicPointerToStruct(t *testing.T) {
 	t.Setenv("DYNCONFIG_TEST_PE_HOST", "example.com")
@@ -63,6 +73,20 @@ func TestParseEnv_RequiredMissing(t *testing.T) {
 	}
 }
 
+// TestParseEnv_AggregateError verifies that the default implementation keeps
+// returning the errors of env.Parse.
+func TestParseEnv_AggregateError(t *testing.T) {
+	type config struct {
+		A string `env:"DYNCONFIG_TEST_PE_MISSING_A,required"`
+		B string `env:"DYNCONFIG_TEST_PE_MISSING_B,required"`
+	}
+	err := ParseEnv(&config{})
+	var aggregate env.AggregateError
+	if !errors.As(err, &aggregate) || len(aggregate.Errors) != 2 {
+		t.Errorf("ParseEnv error = %#v, want env.AggregateError with 2 errors", err)
+	}
+}
+
 func TestParseEnv_ParseError(t *testing.T) {
 	t.Setenv("DYNCONFIG_TEST_PE_BADINT", "not-a-number")
 
@@ -93,3 +117,194 @@ func TestParseEnv_Customizable(t *testing.T) {
 		t.Error("custom ParseEnv was not invoked")
 	}
 }
+
+func TestParseEnvWithOptions_Prefix(t *testing.T) {
+	t.Setenv("DYNCONFIG_TEST_PREFIX_A_PORT", "1111")
+	t.Setenv("DYNCONFIG_TEST_PREFIX_B_PORT", "2222")
+
+	type config struct {
+		Port int `env:"PORT"`
+	}
+	a, b := &config{}, &config{}
+	if err := ParseEnvWithOptions(a, EnvOptions{Prefix: "DYNCONFIG_TEST_PREFIX_A_"}); err != nil {
+		t.Fatalf("ParseEnvWithOptions: %s", err)
+	}
+	if err := ParseEnvWithOptions(b, EnvOptions{Prefix: "DYNCONFIG_TEST_PREFIX_B_"}); err != nil {
+		t.Fatalf("ParseEnvWithOptions: %s", err)
+	}
+	if a.Port != 1111 || b.Port != 2222 {
+		t.Errorf("got ports %d and %d, want 1111 and 2222", a.Port, b.Port)
+	}
+}
+
+func TestParseEnvWithOptions_DeriveNames(t *testing.T) {
+	t.Setenv("DYNCONFIG_TEST_DN_MAX_CONNS", "7")
+	t.Setenv("DYNCONFIG_TEST_DN_DATABASE_HOST", "db.example.com")
+	t.Setenv("DYNCONFIG_TEST_DN_DATABASE_USER_NAME", "admin")
+	t.Setenv("DYNCONFIG_TEST_DN_CACHE_TTL", "5")
+	t.Setenv("DYNCONFIG_TEST_DN_API_KEY", "secret")
+
+	type database struct {
+		Host string
+		User string `env:"USER_NAME"`
+	}
+	type cache struct {
+		TTL int
+	}
+	type config struct {
+		MaxConns int
+		APIKey   string
+		Database database
+		Cache    *cache
+		Missing  *cache // nil pointers are not traversed
+		internal string
+	}
+	cfg := &config{Cache: &cache{}}
+	err := ParseEnvWithOptions(cfg, EnvOptions{Prefix: "DYNCONFIG_TEST_DN_", DeriveNames: true})
+	if err != nil {
+		t.Fatalf("ParseEnvWithOptions: %s", err)
+	}
+	if cfg.MaxConns != 7 || cfg.APIKey != "secret" {
+		t.Errorf("got MaxConns=%d APIKey=%q, want 7 and secret", cfg.MaxConns, cfg.APIKey)
+	}
+	if cfg.Database.Host != "db.example.com" || cfg.Database.User != "admin" {
+		t.Errorf("got Database %+v", cfg.Database)
+	}
+	if cfg.Cache.TTL != 5 {
+		t.Errorf("got Cache.TTL=%d, want 5", cfg.Cache.TTL)
+	}
+	if cfg.Missing != nil {
+		t.Error("nil nested pointer must stay nil")
+	}
+}
+
+// TestParseEnvWithOptions_EnvPrefixTag verifies the envPrefix tag of nested
+// structs is appended to the options prefix, with and without DeriveNames.
+func TestParseEnvWithOptions_EnvPrefixTag(t *testing.T) {
+	t.Setenv("DYNCONFIG_TEST_EP_DB_HOST", "db.example.com")
+
+	type config struct {
+		Database struct {
+			Host string `env:"HOST"`
+		} `envPrefix:"DB_"`
+	}
+	for _, derive := range []bool{false, true} {
+		cfg := &config{}
+		err := ParseEnvWithOptions(cfg, EnvOptions{Prefix: "DYNCONFIG_TEST_EP_", DeriveNames: derive})
+		if err != nil {
+			t.Fatalf("ParseEnvWithOptions: %s", err)
+		}
+		if cfg.Database.Host != "db.example.com" {
+			t.Errorf("DeriveNames=%t: Host = %q, want db.example.com", derive, cfg.Database.Host)
+		}
+	}
+}
+
+func TestParseEnvWithOptions_RequiredUsesPrefix(t *testing.T) {
+	t.Setenv("DYNCONFIG_TEST_REQ", "unprefixed is not enough")
+
+	type config struct {
+		Key string `env:"DYNCONFIG_TEST_REQ,required"`
+	}
+	err := ParseEnvWithOptions(&config{}, EnvOptions{Prefix: "MISSING_"})
+	if err == nil || !strings.Contains(err.Error(), "MISSING_DYNCONFIG_TEST_REQ") {
+		t.Errorf("error = %v, want required error naming MISSING_DYNCONFIG_TEST_REQ", err)
+	}
+}
+
+func TestParseEnvWithOptions_NotStructPointer(t *testing.T) {
+	var i int
+	if err := ParseEnvWithOptions(&i, EnvOptions{}); err == nil {
+		t.Error("expected error for pointer to non-struct")
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"Host":       "HOST",
+		"MaxConns":   "MAX_CONNS",
+		"DBHost":     "DB_HOST",
+		"APIKey":     "API_KEY",
+		"HTTPServer": "HTTP_SERVER",
+		"UserID":     "USER_ID",
+		"Port2Max":   "PORT2_MAX",
+		"TTL":        "TTL",
+	}
+	for name, want := range tests {
+		if got := envName(name); got != want {
+			t.Errorf("envName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestParseEnvWithSources(t *testing.T) {
+	t.Setenv("DYNCONFIG_TEST_SRC_HOST", "from-env")
+	t.Setenv("DYNCONFIG_TEST_SRC_EMPTY", "")
+
+	type config struct {
+		Host    string `env:"HOST"`
+		Port    int    `env:"PORT" envDefault:"8080"`
+		Empty   string `env:"EMPTY"`
+		Unset   string `env:"UNSET"`
+		Ignored string
+	}
+	cfg := &config{Empty: "kept", Unset: "kept"}
+	sources, err := ParseEnvWithSources(cfg, EnvOptions{Prefix: "DYNCONFIG_TEST_SRC_"})
+	if err != nil {
+		t.Fatalf("ParseEnvWithSources: %s", err)
+	}
+	want := dynconfig.Sources{
+		"Host":  {Kind: dynconfig.SourceEnv, Name: "DYNCONFIG_TEST_SRC_HOST"},
+		"Port":  {Kind: dynconfig.SourceDefault, Name: "DYNCONFIG_TEST_SRC_PORT"},
+		"Empty": {Kind: dynconfig.SourceFile},
+		"Unset": {Kind: dynconfig.SourceFile},
+	}
+	if !reflect.DeepEqual(sources, want) {
+		t.Errorf("got %v, want %v", sources, want)
+	}
+	if cfg.Host != "from-env" || cfg.Port != 8080 || cfg.Empty != "kept" || cfg.Unset != "kept" {
+		t.Errorf("got %+v", cfg)
+	}
+}
+
+func TestParseEnv_SecretFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db_password")
+	if err := os.WriteFile(path, []byte("  s3cret\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	t.Setenv("DYNCONFIG_TEST_SECRET_PASSWORD_FILE", path)
+	t.Setenv("DYNCONFIG_TEST_SECRET_USER", "from-env")
+	t.Setenv("DYNCONFIG_TEST_SECRET_USER_FILE", path) // Ignored because USER is set
+
+	type config struct {
+		Password string `env:"DYNCONFIG_TEST_SECRET_PASSWORD,required"`
+		User     string `env:"DYNCONFIG_TEST_SECRET_USER"`
+	}
+	cfg := &config{}
+	sources, err := ParseEnvWithSources(cfg, EnvOptions{})
+	if err != nil {
+		t.Fatalf("ParseEnvWithSources: %s", err)
+	}
+	if cfg.Password != "s3cret" || cfg.User != "from-env" {
+		t.Errorf("got %+v", cfg)
+	}
+	want := dynconfig.Sources{
+		"Password": {Kind: dynconfig.SourceEnv, Name: "DYNCONFIG_TEST_SECRET_PASSWORD_FILE"},
+		"User":     {Kind: dynconfig.SourceEnv, Name: "DYNCONFIG_TEST_SECRET_USER"},
+	}
+	if !reflect.DeepEqual(sources, want) {
+		t.Errorf("got %v, want %v", sources, want)
+	}
+}
+
+func TestParseEnv_SecretFileMissing(t *testing.T) {
+	t.Setenv("DYNCONFIG_TEST_SECRET_MISSING_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	type config struct {
+		Value string `env:"DYNCONFIG_TEST_SECRET_MISSING"`
+	}
+	err := ParseEnv(&config{})
+	if err == nil || !strings.Contains(err.Error(), "DYNCONFIG_TEST_SECRET_MISSING_FILE") {
+		t.Errorf("error = %v, want error naming DYNCONFIG_TEST_SECRET_MISSING_FILE", err)
+	}
+}
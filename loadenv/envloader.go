@@ -0,0 +1,99 @@
+package loadenv
+
+import (
+	"reflect"
+
+	"github.com/ungerik/go-dynconfig"
+	"github.com/ungerik/go-fs"
+)
+
+// LoadEnv loads configuration from environment variables only,
+// ignoring the passed file.
+//
+// It parses the environment into a zero value of T with ParseEnv,
+// so defaults come from envDefault struct tags. If T is a pointer type,
+// a new value is allocated for it to point to.
+//
+// Secret files referenced by NAME_FILE variables are reported with
+// dynconfig.ReportDependencies like with LoadEnvJSON.
+//
+// Use it as load function of a Loader without file, see NewEnvLoader.
+func LoadEnv[T any](file fs.File) (config T, err error) {
+	config = newEnvConfig[T]()
+	err = ParseEnv(&config)
+	if err != nil {
+		return *new(T), err
+	}
+	reportEnv(file, &config, EnvOptions{})
+	return config, nil
+}
+
+// LoadEnvWithOptions returns a load function that loads configuration from
+// environment variables only, mapped as configured by opts
+// (see ParseEnvWithOptions).
+//
+// Unlike LoadEnv it does not call the replaceable ParseEnv function.
+//
+// Example:
+//
+//	loader := dynconfig.NewLoader(
+//	    "",
+//	    loadenv.LoadEnvWithOptions[*Config](loadenv.EnvOptions{Prefix: "APP_", DeriveNames: true}),
+//	    nil, nil, nil, nil,
+//	)
+func LoadEnvWithOptions[T any](opts EnvOptions) func(file fs.File) (T, error) {
+	return func(file fs.File) (config T, err error) {
+		config = newEnvConfig[T]()
+		err = ParseEnvWithOptions(&config, opts)
+		if err != nil {
+			return *new(T), err
+		}
+		reportEnv(file, &config, opts)
+		return config, nil
+	}
+}
+
+// NewEnvLoader returns a dynconfig.Loader that loads configuration of type T
+// from environment variables only, using LoadEnv, so code can depend on
+// *dynconfig.Loader[T] no matter if the configuration comes from a file.
+//
+// The Loader has an empty file path and no save function.
+// Like dynconfig.NewLoader it doesn't load the configuration yet.
+// The configuration is loaded by the first Get or Load call and cached
+// until it is invalidated, so environment changes take effect only after
+// calling Reload or Invalidate, or on the signals passed to ReloadOn.
+// Watch only watches secret files referenced by NAME_FILE variables.
+//
+// Parameters:
+//   - onLoad: Optional callback called after successful load, for example to validate (can be nil)
+//   - onError: Optional callback to handle errors (can be nil)
+//   - onInvalidate: Optional callback called when config is invalidated (can be nil)
+//   - opts: Optional settings like dynconfig.WithLogger
+//
+// Example:
+//
+//	type Config struct {
+//	    Port     int    `env:"PORT" envDefault:"8080"`
+//	    Database string `env:"DATABASE_URL,required"`
+//	}
+//
+//	loader := loadenv.NewEnvLoader[*Config](nil, nil, nil)
+//	if _, err := loader.Load(); err != nil {
+//	    log.Fatal(err)
+//	}
+//	stop := loader.ReloadOn(syscall.SIGHUP)
+//	defer stop()
+//
+//	config := loader.Get()
+func NewEnvLoader[T any](onLoad func(T) T, onError func(error) T, onInvalidate func(), opts ...dynconfig.Option) *dynconfig.Loader[T] {
+	return dynconfig.NewLoader("", LoadEnv[T], nil, onLoad, onError, onInvalidate, opts...)
+}
+
+// newEnvConfig returns the zero value of T, or a pointer to a new zero
+// value if T is a pointer type.
+func newEnvConfig[T any]() (config T) {
+	if v := reflect.ValueOf(&config).Elem(); v.Kind() == reflect.Pointer {
+		v.Set(reflect.New(v.Type().Elem()))
+	}
+	return config
+}
@@ -0,0 +1,113 @@
+package loadenv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ungerik/go-dynconfig"
+)
+
+type envOnlyConfig struct {
+	Port int    `env:"DYNCONFIG_TEST_ENVONLY_PORT" envDefault:"8080"`
+	Host string `env:"DYNCONFIG_TEST_ENVONLY_HOST,required"`
+}
+
+func TestNewEnvLoader(t *testing.T) {
+	t.Setenv("DYNCONFIG_TEST_ENVONLY_HOST", "first")
+
+	loader := NewEnvLoader[*envOnlyConfig](nil, nil, nil)
+	if loader.Loaded() {
+		t.Fatal("NewEnvLoader must not load")
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	if cfg == nil || cfg.Host != "first" || cfg.Port != 8080 {
+		t.Fatalf("got %+v", cfg)
+	}
+	want := dynconfig.Sources{
+		"Host": {Kind: dynconfig.SourceEnv, Name: "DYNCONFIG_TEST_ENVONLY_HOST"},
+		"Port": {Kind: dynconfig.SourceDefault, Name: "DYNCONFIG_TEST_ENVONLY_PORT"},
+	}
+	if got := loader.Sources(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sources() = %v, want %v", got, want)
+	}
+
+	t.Setenv("DYNCONFIG_TEST_ENVONLY_HOST", "second")
+	if got := loader.Get().Host; got != "first" {
+		t.Errorf("got %q, want cached first", got)
+	}
+	if err := loader.Reload(); err != nil {
+		t.Fatalf("Reload: %s", err)
+	}
+	if got := loader.Get().Host; got != "second" {
+		t.Errorf("got %q, want second after Reload", got)
+	}
+	if err := loader.Set(&envOnlyConfig{}); err == nil {
+		t.Error("expected error for Set without save function")
+	}
+}
+
+func TestNewEnvLoader_RequiredError(t *testing.T) {
+	onErrorCalled := false
+	loader := NewEnvLoader(nil, func(error) envOnlyConfig {
+		onErrorCalled = true
+		return envOnlyConfig{Host: "fallback"}
+	}, nil)
+	cfg, err := loader.Load()
+	if err == nil {
+		t.Error("expected error for missing required variable")
+	}
+	if !onErrorCalled || cfg.Host != "fallback" {
+		t.Errorf("got %+v, want onError fallback", cfg)
+	}
+}
+
+// TestNewEnvLoader_WatchSecretFile verifies Watch on a Loader without file
+// watches the secret files referenced by NAME_FILE variables.
+func TestNewEnvLoader_WatchSecretFile(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "host")
+	if err := os.WriteFile(secret, []byte("old-host"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	t.Setenv("DYNCONFIG_TEST_ENVONLY_HOST_FILE", secret)
+
+	loader := NewEnvLoader[envOnlyConfig](nil, nil, nil)
+	if err := loader.Watch(); err != nil {
+		t.Fatalf("Watch: %s", err)
+	}
+	defer loader.Unwatch() //nolint:errcheck
+
+	if got := loader.Get().Host; got != "old-host" {
+		t.Fatalf("got %q, want old-host", got)
+	}
+	if err := os.WriteFile(secret, []byte("new-host"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for loader.Get().Host != "new-host" {
+		if time.Now().After(deadline) {
+			t.Fatal("secret file change did not reload the configuration")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLoadEnvWithOptions(t *testing.T) {
+	t.Setenv("DYNCONFIG_TEST_ENVOPT_MAX_CONNS", "12")
+
+	type config struct {
+		MaxConns int
+	}
+	cfg, err := LoadEnvWithOptions[config](EnvOptions{Prefix: "DYNCONFIG_TEST_ENVOPT_", DeriveNames: true})("")
+	if err != nil {
+		t.Fatalf("LoadEnvWithOptions: %s", err)
+	}
+	if cfg.MaxConns != 12 {
+		t.Errorf("got MaxConns=%d, want 12", cfg.MaxConns)
+	}
+}
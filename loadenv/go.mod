@@ -2,8 +2,13 @@ module github.com/ungerik/go-dynconfig/loadenv
 
 go 1.25.0
 
+// Replaced with the local version of the root module until the release
+// of the root module with Sources and ReportSources is tagged
+replace github.com/ungerik/go-dynconfig => ../
+
 require (
 	github.com/caarlos0/env/v7 v7.1.0
+	github.com/ungerik/go-dynconfig v0.0.0-00010101000000-000000000000
 	github.com/ungerik/go-fs v0.0.0-20260629070125-ad84dc607eca
 )
 
@@ -3,6 +3,7 @@ package loadenv
 import (
 	"context"
 
+	"github.com/ungerik/go-dynconfig"
 	"github.com/ungerik/go-fs"
 )
 
@@ -16,6 +17,12 @@ import (
 // Environment variables are parsed using the `env` struct tag.
 // See ParseEnv for details on struct tag format and supported types.
 //
+// The sources of the fields mapped to environment variables are reported
+// with dynconfig.ReportSources, so Loader.Sources tells which values were
+// overridden, and secret files referenced by NAME_FILE variables are
+// reported with dynconfig.ReportDependencies, so a watching Loader reloads
+// when a secret is rotated. The reports assume the default mapping of ParseEnv.
+//
 // Type Parameters:
 //   - T: The configuration type to unmarshal from JSON
 //
@@ -53,7 +60,15 @@ import (
 //	    nil,
 //	)
 func LoadEnvJSON[T any](file fs.File) (config T, err error) {
-	err = file.ReadJSON(context.Background(), &config)
+	return LoadEnvJSONContext[T](context.Background(), file)
+}
+
+// LoadEnvJSONContext is the context-aware variant of LoadEnvJSON for
+// dynconfig.NewLoaderContext and dynconfig.LoadAndWatchContext.
+// ctx is passed to the go-fs file system, so reading a remote file can be
+// canceled.
+func LoadEnvJSONContext[T any](ctx context.Context, file fs.File) (config T, err error) {
+	config, err = dynconfig.LoadJSONContext[T](ctx, file)
 	if err != nil {
 		return *new(T), err
 	}
@@ -61,6 +76,7 @@ func LoadEnvJSON[T any](file fs.File) (config T, err error) {
 	if err != nil {
 		return *new(T), err
 	}
+	reportEnv(file, &config, EnvOptions{})
 	return config, nil
 }
 
@@ -74,6 +90,12 @@ func LoadEnvJSON[T any](file fs.File) (config T, err error) {
 // Environment variables are parsed using the `env` struct tag.
 // See ParseEnv for details on struct tag format and supported types.
 //
+// The sources of the fields mapped to environment variables are reported
+// with dynconfig.ReportSources, so Loader.Sources tells which values were
+// overridden, and secret files referenced by NAME_FILE variables are
+// reported with dynconfig.ReportDependencies, so a watching Loader reloads
+// when a secret is rotated. The reports assume the default mapping of ParseEnv.
+//
 // Type Parameters:
 //   - T: The configuration type to unmarshal from XML
 //
@@ -99,7 +121,15 @@ func LoadEnvJSON[T any](file fs.File) (config T, err error) {
 //	// Result: {Database: "prod.db", Port: 8080, Debug: false}
 //	// DB_NAME and APP_PORT from environment override XML values
 func LoadEnvXML[T any](file fs.File) (config T, err error) {
-	err = file.ReadXML(context.Background(), &config)
+	return LoadEnvXMLContext[T](context.Background(), file)
+}
+
+// LoadEnvXMLContext is the context-aware variant of LoadEnvXML for
+// dynconfig.NewLoaderContext and dynconfig.LoadAndWatchContext.
+// ctx is passed to the go-fs file system, so reading a remote file can be
+// canceled.
+func LoadEnvXMLContext[T any](ctx context.Context, file fs.File) (config T, err error) {
+	config, err = dynconfig.LoadXMLContext[T](ctx, file)
 	if err != nil {
 		return *new(T), err
 	}
@@ -107,5 +137,168 @@ func LoadEnvXML[T any](file fs.File) (config T, err error) {
 	if err != nil {
 		return *new(T), err
 	}
+	reportEnv(file, &config, EnvOptions{})
 	return config, nil
 }
+
+// LoadEnvJSONWithOptions returns a load function that loads JSON configuration
+// and overrides values with environment variables mapped as configured by
+// opts (see ParseEnvWithOptions).
+//
+// Unlike LoadEnvJSON it does not call the replaceable ParseEnv function.
+// Like LoadEnvJSON it reports the sources of the fields mapped to
+// environment variables and secret files for Loader.Sources and
+// Loader.Dependencies.
+//
+// Example:
+//
+//	loader := dynconfig.MustLoadAndWatch(
+//	    "billing.json",
+//	    loadenv.LoadEnvJSONWithOptions[*Config](loadenv.EnvOptions{
+//	        Prefix:      "BILLING_",
+//	        DeriveNames: true,
+//	    }),
+//	    nil, nil, nil, nil,
+//	)
+func LoadEnvJSONWithOptions[T any](opts EnvOptions) func(file fs.File) (T, error) {
+	return func(file fs.File) (config T, err error) {
+		config, err = dynconfig.LoadJSONContext[T](context.Background(), file)
+		if err != nil {
+			return *new(T), err
+		}
+		err = ParseEnvWithOptions(&config, opts)
+		if err != nil {
+			return *new(T), err
+		}
+		reportEnv(file, &config, opts)
+		return config, nil
+	}
+}
+
+// LoadEnvJSONPrefix returns a load function that works like LoadEnvJSON but
+// prepends prefix to every environment variable name, so two services using
+// the same config type can run in one environment without colliding.
+//
+// Example:
+//
+//	type Config struct {
+//	    Port int `json:"port" env:"PORT"`
+//	}
+//
+//	// Reads the port override from BILLING_PORT instead of PORT
+//	loader := dynconfig.MustLoadAndWatch(
+//	    "billing.json",
+//	    loadenv.LoadEnvJSONPrefix[*Config]("BILLING_"),
+//	    nil, nil, nil, nil,
+//	)
+func LoadEnvJSONPrefix[T any](prefix string) func(file fs.File) (T, error) {
+	return LoadEnvJSONWithOptions[T](EnvOptions{Prefix: prefix})
+}
+
+// LoadEnvXMLWithOptions returns a load function that loads XML configuration
+// and overrides values with environment variables mapped as configured by
+// opts (see ParseEnvWithOptions).
+//
+// Unlike LoadEnvXML it does not call the replaceable ParseEnv function.
+// Like LoadEnvXML it reports the sources of the fields mapped to
+// environment variables and secret files for Loader.Sources and
+// Loader.Dependencies.
+func LoadEnvXMLWithOptions[T any](opts EnvOptions) func(file fs.File) (T, error) {
+	return func(file fs.File) (config T, err error) {
+		config, err = dynconfig.LoadXMLContext[T](context.Background(), file)
+		if err != nil {
+			return *new(T), err
+		}
+		err = ParseEnvWithOptions(&config, opts)
+		if err != nil {
+			return *new(T), err
+		}
+		reportEnv(file, &config, opts)
+		return config, nil
+	}
+}
+
+// LoadEnvXMLPrefix returns a load function that works like LoadEnvXML but
+// prepends prefix to every environment variable name.
+// See LoadEnvJSONPrefix.
+func LoadEnvXMLPrefix[T any](prefix string) func(file fs.File) (T, error) {
+	return LoadEnvXMLWithOptions[T](EnvOptions{Prefix: prefix})
+}
+
+// LoadEnvJSONWithSources loads JSON configuration from file and overrides
+// values with environment variables mapped as configured by opts like
+// LoadEnvJSONWithOptions, and returns the configuration together with the
+// source of every field mapped to an environment variable, the same as
+// reported to Loader.Sources. ctx is passed to the go-fs file system.
+//
+// Example:
+//
+//	config, sources, err := loadenv.LoadEnvJSONWithSources[Config](ctx, "config.json", loadenv.EnvOptions{})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(sources["Port"]) // env APP_PORT or file config.json
+func LoadEnvJSONWithSources[T any](ctx context.Context, file fs.File, opts EnvOptions) (config T, sources dynconfig.Sources, err error) {
+	config, err = dynconfig.LoadJSONContext[T](ctx, file)
+	if err != nil {
+		return *new(T), nil, err
+	}
+	err = ParseEnvWithOptions(&config, opts)
+	if err != nil {
+		return *new(T), nil, err
+	}
+	sources, _ = envReport(file, &config, opts)
+	return config, sources, nil
+}
+
+// LoadEnvXMLWithSources is like LoadEnvJSONWithSources for XML files.
+func LoadEnvXMLWithSources[T any](ctx context.Context, file fs.File, opts EnvOptions) (config T, sources dynconfig.Sources, err error) {
+	config, err = dynconfig.LoadXMLContext[T](ctx, file)
+	if err != nil {
+		return *new(T), nil, err
+	}
+	err = ParseEnvWithOptions(&config, opts)
+	if err != nil {
+		return *new(T), nil, err
+	}
+	sources, _ = envReport(file, &config, opts)
+	return config, sources, nil
+}
+
+// reportEnv reports the sources of the fields of config mapped to
+// environment variables with dynconfig.ReportSources, and the secret files
+// referenced by their NAME_FILE variables with dynconfig.ReportDependencies.
+func reportEnv(file fs.File, config any, opts EnvOptions) {
+	sources, secretFiles := envReport(file, config, opts)
+	if sources != nil {
+		dynconfig.ReportSources(file, sources)
+	}
+	dynconfig.ReportDependencies(file, secretFiles...)
+}
+
+// envReport returns the sources of the fields of config mapped to
+// environment variables and the secret files referenced by their NAME_FILE
+// variables. Fields that kept their value are reported as read from file,
+// or not at all if file is empty.
+func envReport(file fs.File, config any, opts EnvOptions) (sources dynconfig.Sources, secretFiles []fs.File) {
+	v, err := envStructValue(config)
+	if err != nil {
+		return nil, nil
+	}
+	environ := environment()
+	sources = envSources(v, opts, environ)
+	for path, source := range sources {
+		switch {
+		case source.Kind != dynconfig.SourceFile:
+		case file == "":
+			// Without a file, like for NewEnvLoader, the field is a zero value
+			delete(sources, path)
+		default:
+			sources[path] = dynconfig.Source{Kind: dynconfig.SourceFile, Name: string(file)}
+		}
+	}
+	for _, path := range envSecretFiles(v, opts, environ) {
+		secretFiles = append(secretFiles, fs.File(path))
+	}
+	return sources, secretFiles
+}
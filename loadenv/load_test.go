@@ -1,8 +1,16 @@
 package loadenv
 
 import (
+	"context"
 	"encoding/xml"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/ungerik/go-dynconfig"
+	"github.com/ungerik/go-fs"
 )
 
 type envJSONConfig struct {
@@ -47,6 +55,24 @@ func TestLoadEnvJSON_Invalid(t *testing.T) {
 	}
 }
 
+func TestLoadEnvJSONContext_Loader(t *testing.T) {
+	t.Setenv("DYNCONFIG_TEST_JSON_PORT", "9090")
+	file := memFile(t, "config.json", `{"host":"from-json","port":8080}`)
+	loader := dynconfig.NewLoaderContext(file, LoadEnvJSONContext[envJSONConfig], nil, nil, nil, nil)
+
+	cfg, err := loader.LoadContext(context.Background())
+	if err != nil {
+		t.Fatalf("LoadContext: %s", err)
+	}
+	want := envJSONConfig{Host: "from-json", Port: 9090}
+	if cfg != want {
+		t.Errorf("got %+v, want %+v", cfg, want)
+	}
+	if got := loader.Sources()["Port"]; got.Kind != dynconfig.SourceEnv {
+		t.Errorf("Sources()[Port] = %v, want environment", got)
+	}
+}
+
 type envXMLConfig struct {
 	XMLName xml.Name `xml:"config"`
 	Host    string   `xml:"host" env:"DYNCONFIG_TEST_XML_HOST"`
@@ -89,3 +115,162 @@ func TestLoadEnvXML_Invalid(t *testing.T) {
 		t.Error("expected error for invalid XML int value")
 	}
 }
+
+func TestLoadEnvJSONPrefix(t *testing.T) {
+	t.Setenv("BILLING_DYNCONFIG_TEST_JSON_PORT", "7070")
+	t.Setenv("DYNCONFIG_TEST_JSON_PORT", "9090") // Must be ignored
+	file := memFile(t, "config.json", `{"host":"from-json","port":8080}`)
+
+	cfg, err := LoadEnvJSONPrefix[envJSONConfig]("BILLING_")(file)
+	if err != nil {
+		t.Fatalf("LoadEnvJSONPrefix: %s", err)
+	}
+	want := envJSONConfig{Host: "from-json", Port: 7070}
+	if cfg != want {
+		t.Errorf("got %+v, want %+v", cfg, want)
+	}
+}
+
+func TestLoadEnvXMLWithOptions(t *testing.T) {
+	t.Setenv("SHOP_HOST", "from-env")
+	file := memFile(t, "config.xml", `<config><host>from-xml</host><port>8080</port></config>`)
+
+	type config struct {
+		XMLName xml.Name `xml:"config"`
+		Host    string   `xml:"host"`
+		Port    int      `xml:"port"`
+	}
+	cfg, err := LoadEnvXMLWithOptions[*config](EnvOptions{Prefix: "SHOP_", DeriveNames: true})(file)
+	if err != nil {
+		t.Fatalf("LoadEnvXMLWithOptions: %s", err)
+	}
+	if cfg.Host != "from-env" || cfg.Port != 8080 {
+		t.Errorf("got host=%q port=%d, want from-env/8080", cfg.Host, cfg.Port)
+	}
+}
+
+func TestLoadEnvJSON_LoaderSources(t *testing.T) {
+	t.Setenv("DYNCONFIG_TEST_JSON_PORT", "9090")
+	file := memFile(t, "config.json", `{"host":"from-json","port":8080}`)
+
+	loader := dynconfig.NewLoader(file, LoadEnvJSON[*envJSONConfig], nil, nil, nil, nil)
+	if _, err := loader.Load(); err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	want := dynconfig.Sources{
+		"Host": {Kind: dynconfig.SourceFile, Name: string(file)},
+		"Port": {Kind: dynconfig.SourceEnv, Name: "DYNCONFIG_TEST_JSON_PORT"},
+	}
+	if got := loader.Sources(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLoadEnvJSONWithSources(t *testing.T) {
+	t.Setenv("SHOP_PORT", "9090")
+	file := memFile(t, "config.json", `{"host":"from-json","port":8080}`)
+
+	type config struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+	cfg, sources, err := LoadEnvJSONWithSources[config](context.Background(), file, EnvOptions{Prefix: "SHOP_", DeriveNames: true})
+	if err != nil {
+		t.Fatalf("LoadEnvJSONWithSources: %s", err)
+	}
+	if want := (config{Host: "from-json", Port: 9090}); cfg != want {
+		t.Errorf("got %+v, want %+v", cfg, want)
+	}
+	want := dynconfig.Sources{
+		"Host": {Kind: dynconfig.SourceFile, Name: string(file)},
+		"Port": {Kind: dynconfig.SourceEnv, Name: "SHOP_PORT"},
+	}
+	if !reflect.DeepEqual(sources, want) {
+		t.Errorf("sources = %v, want %v", sources, want)
+	}
+}
+
+// TestLoadEnvJSON_SecretFileRotation verifies a watching Loader reloads
+// when a secret file referenced by a NAME_FILE variable changes.
+func TestLoadEnvJSON_SecretFileRotation(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "host")
+	if err := os.WriteFile(secret, []byte("old-host\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	t.Setenv("DYNCONFIG_TEST_JSON_HOST_FILE", secret)
+	file := fs.File(filepath.Join(t.TempDir(), "config.json"))
+	if err := file.WriteAllString(`{"host":"from-json","port":8080}`); err != nil {
+		t.Fatalf("WriteAllString: %s", err)
+	}
+
+	loader, err := dynconfig.LoadAndWatch(file, LoadEnvJSON[envJSONConfig], nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadAndWatch: %s", err)
+	}
+	defer loader.Unwatch() //nolint:errcheck
+
+	if got := loader.Get().Host; got != "old-host" {
+		t.Fatalf("Host = %q, want old-host", got)
+	}
+	if got := loader.Dependencies(); !reflect.DeepEqual(got, []fs.File{fs.File(secret)}) {
+		t.Errorf("Dependencies() = %v, want [%s]", got, secret)
+	}
+
+	if err := os.WriteFile(secret, []byte("new-host\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for loader.Get().Host != "new-host" {
+		if time.Now().After(deadline) {
+			t.Fatal("secret file rotation did not reload the configuration")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestLoaders_SecretFileDependencies verifies that every load function
+// without context reports the secret files referenced by NAME_FILE
+// variables to the Loader.
+func TestLoaders_SecretFileDependencies(t *testing.T) {
+	type config struct {
+		XMLName xml.Name `json:"-" xml:"config"`
+		Host    string   `json:"host" xml:"host" env:"DYNCONFIG_TEST_DEP_HOST"`
+	}
+	secret := filepath.Join(t.TempDir(), "host")
+	if err := os.WriteFile(secret, []byte("from-secret\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	t.Setenv("DYNCONFIG_TEST_DEP_HOST_FILE", secret)
+	t.Setenv("APP_DYNCONFIG_TEST_DEP_HOST_FILE", secret)
+	opts := EnvOptions{Prefix: "APP_"}
+
+	tests := []struct {
+		name string
+		file string
+		load func(fs.File) (config, error)
+	}{
+		{"LoadEnvJSON", `{"host":"from-file"}`, LoadEnvJSON[config]},
+		{"LoadEnvXML", `<config><host>from-file</host></config>`, LoadEnvXML[config]},
+		{"LoadEnvJSONPrefix", `{"host":"from-file"}`, LoadEnvJSONPrefix[config]("APP_")},
+		{"LoadEnvXMLPrefix", `<config><host>from-file</host></config>`, LoadEnvXMLPrefix[config]("APP_")},
+		{"LoadEnvJSONWithOptions", `{"host":"from-file"}`, LoadEnvJSONWithOptions[config](opts)},
+		{"LoadEnvXMLWithOptions", `<config><host>from-file</host></config>`, LoadEnvXMLWithOptions[config](opts)},
+		{"LoadEnv", "", LoadEnv[config]},
+		{"LoadEnvWithOptions", "", LoadEnvWithOptions[config](opts)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := dynconfig.NewLoader(memFile(t, "config", tt.file), tt.load, nil, nil, nil, nil)
+			cfg, err := loader.Load()
+			if err != nil {
+				t.Fatalf("Load: %s", err)
+			}
+			if cfg.Host != "from-secret" {
+				t.Errorf("Host = %q, want from-secret", cfg.Host)
+			}
+			if got := loader.Dependencies(); !reflect.DeepEqual(got, []fs.File{fs.File(secret)}) {
+				t.Errorf("Dependencies() = %v, want [%s]", got, secret)
+			}
+		})
+	}
+}
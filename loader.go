@@ -90,11 +90,17 @@
 package dynconfig
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/ungerik/go-fs"
 )
@@ -131,14 +137,34 @@ import (
 type Loader[T any] struct {
 	mtx          sync.Mutex
 	file         fs.File
-	load         func(fs.File) (T, error)
-	save         func(fs.File, T) error
+	load         LoadContextFunc[T]
+	save         SaveContextFunc[T]
 	onLoad       func(T) T
 	onError      func(error) T
 	onInvalidate func()
 	unwatch      func() error
 	config       T
+	sources      Sources
+	interpolated bool // config holds values expanded by Interpolate
 	loaded       bool
+
+	unreportedContent bool // the last load didn't call ReportContent, see LoadVersioned
+
+	dependencies        []fs.File
+	unwatchDependencies func() error
+
+	opts   loaderOptions
+	logger *slog.Logger // nil without WithLogger
+
+	closed  bool
+	onClose []func()
+
+	status loaderStatus
+
+	subscribers []*subscriber[T]
+	pending     []configChange[T] // Changes for the subscribers
+	notifying   bool              // Passing pending to the subscribers
+	configured  bool              // config was set at least once
 }
 
 // NewLoader returns a new Loader for the type T without loading the configuration yet.
@@ -153,6 +179,7 @@ type Loader[T any] struct {
 //   - onLoad: Optional callback called after successful load (can be nil)
 //   - onError: Optional callback to handle errors (can be nil)
 //   - onInvalidate: Optional callback called when config is invalidated (can be nil)
+//   - opts: Optional settings like WithBackups
 //
 // Example:
 //
@@ -187,7 +214,38 @@ func NewLoader[T any](
 	onLoad func(T) T,
 	onError func(error) T,
 	onInvalidate func(),
+	opts ...Option,
+) *Loader[T] {
+	return NewLoaderContext(file, ContextLoad(load), ContextSave(save), onLoad, onError, onInvalidate, opts...)
+}
+
+// NewLoaderContext is like NewLoader but takes context-aware load and save
+// functions like LoadJSONContext and SaveJSONContext, which get the context
+// passed to LoadContext, MutateContext, SetContext and SetIfVersionContext.
+// Use ContextLoad and ContextSave to adapt functions without context.
+//
+// Example:
+//
+//	loader := dynconfig.NewLoaderContext(
+//	    fs.File("s3://bucket/config.json"),
+//	    dynconfig.LoadJSONContext[Config],
+//	    dynconfig.SaveJSONContext[Config]("  "),
+//	    nil, nil, nil,
+//	)
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	config, err := loader.LoadContext(ctx)
+func NewLoaderContext[T any](
+	file fs.File,
+	load LoadContextFunc[T],
+	save SaveContextFunc[T],
+	onLoad func(T) T,
+	onError func(error) T,
+	onInvalidate func(),
+	opts ...Option,
 ) *Loader[T] {
+	o := newLoaderOptions(opts)
 	return &Loader[T]{
 		file:         file,
 		load:         load,
@@ -195,6 +253,8 @@ func NewLoader[T any](
 		onLoad:       onLoad,
 		onError:      onError,
 		onInvalidate: onInvalidate,
+		opts:         o,
+		logger:       newLoaderLogger[T](o, string(file)),
 	}
 }
 
@@ -211,6 +271,7 @@ func NewLoader[T any](
 //   - onLoad: Optional callback called after each successful load (can be nil)
 //   - onError: Optional callback to handle load errors (can be nil)
 //   - onInvalidate: Optional callback called when config is invalidated due to file changes (can be nil)
+//   - opts: Optional settings like WithBackups
 //
 // File Watching:
 //   - The file's directory is watched for file creation and modification events
@@ -276,6 +337,25 @@ func LoadAndWatch[T any](
 	onLoad func(T) T,
 	onError func(error) T,
 	onInvalidate func(),
+	opts ...Option,
+) (*Loader[T], error) {
+	if load == nil {
+		return nil, errors.New("load function must not be nil")
+	}
+	return LoadAndWatchContext(context.Background(), file, ContextLoad(load), ContextSave(save), onLoad, onError, onInvalidate, opts...)
+}
+
+// LoadAndWatchContext is like LoadAndWatch but takes context-aware load and
+// save functions (see NewLoaderContext) and passes ctx to the initial load.
+func LoadAndWatchContext[T any](
+	ctx context.Context,
+	file fs.File,
+	load LoadContextFunc[T],
+	save SaveContextFunc[T],
+	onLoad func(T) T,
+	onError func(error) T,
+	onInvalidate func(),
+	opts ...Option,
 ) (*Loader[T], error) {
 	if load == nil {
 		return nil, errors.New("load function must not be nil")
@@ -283,12 +363,12 @@ func LoadAndWatch[T any](
 	if file == "" {
 		return nil, errors.New("file path must not be empty")
 	}
-	l := NewLoader(file, load, save, onLoad, onError, onInvalidate)
+	l := NewLoaderContext(file, load, save, onLoad, onError, onInvalidate, opts...)
 	err := l.Watch() // May invalidate before load which is OK
 	if err != nil {
 		return nil, err
 	}
-	_, err = l.Load()
+	_, err = l.LoadContext(ctx)
 	if err != nil && onError == nil {
 		// Unwatch and return error if no onError
 		return nil, errors.Join(err, l.unwatch())
@@ -335,6 +415,7 @@ func MustLoadAndWatch[T any](
 	onLoad func(T) T,
 	onError func(error) T,
 	onInvalidate func(),
+	opts ...Option,
 ) *Loader[T] {
 	l, err := LoadAndWatch(
 		file,
@@ -343,6 +424,7 @@ func MustLoadAndWatch[T any](
 		onLoad,
 		onError,
 		onInvalidate,
+		opts...,
 	)
 	if err != nil {
 		panic(err)
@@ -418,6 +500,7 @@ func (l *Loader[T]) Invalidate() {
 	l.loaded = false
 	l.mtx.Unlock()
 
+	l.logAttrs(context.Background(), slog.LevelDebug, "config invalidated")
 	if l.onInvalidate != nil {
 		l.onInvalidate()
 	}
@@ -430,6 +513,8 @@ func (l *Loader[T]) Invalidate() {
 //   - Automatically calls Invalidate() when the file is created or modified
 //   - File deletion does NOT trigger invalidation (maintains last known config)
 //   - File recreation DOES trigger invalidation
+//   - Dependencies reported by the load function with ReportDependencies are
+//     watched the same way (see Dependencies)
 //
 // Returns an error if:
 //   - Called on a nil Loader
@@ -441,6 +526,9 @@ func (l *Loader[T]) Invalidate() {
 // Note: The file itself doesn't need to exist for watching to start,
 // only its parent directory must exist.
 //
+// A Loader with an empty file path, like one created by loadenv.NewEnvLoader,
+// only watches its dependencies.
+//
 // Example:
 //
 //	loader := dynconfig.NewLoader(...)
@@ -455,18 +543,32 @@ func (l *Loader[T]) Watch() error {
 	l.mtx.Lock()
 	defer l.mtx.Unlock()
 
+	if l.closed {
+		return ErrClosed
+	}
 	if l.unwatch != nil {
 		return fmt.Errorf("config file already watched: %s", l.file)
 	}
-	unwatch, err := l.file.Dir().Watch(func(f fs.File, e fs.Event) {
-		if f == l.file && (e.HasCreate() || e.HasWrite()) {
-			l.Invalidate()
+	if l.file == "" {
+		// Without a file, like for loaders of environment variables,
+		// only the dependencies are watched
+		l.unwatch = func() error { return nil }
+	} else {
+		unwatch, err := l.file.Dir().Watch(func(f fs.File, e fs.Event) {
+			if f == l.file && (e.HasCreate() || e.HasWrite()) {
+				l.fileChanged()
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("watch config file error: %w", err)
 		}
-	})
+		l.unwatch = unwatch
+	}
+	err := l.watchDependencies()
 	if err != nil {
-		return fmt.Errorf("watch config file error: %w", err)
+		return errors.Join(err, l.stopWatching())
 	}
-	l.unwatch = unwatch
+	l.logAttrs(context.Background(), slog.LevelDebug, "config watch started")
 	return nil
 }
 
@@ -497,11 +599,99 @@ func (l *Loader[T]) Unwatch() error {
 	if l.unwatch == nil {
 		return fmt.Errorf("config file not watched: %s", l.file)
 	}
-	err := l.unwatch()
+	return l.stopWatching()
+}
+
+// stopWatching stops watching the file and its dependencies.
+// The caller must hold l.mtx.
+func (l *Loader[T]) stopWatching() error {
+	var err error
+	if l.unwatchDependencies != nil {
+		err = l.unwatchDependencies()
+		l.unwatchDependencies = nil
+	}
+	err = errors.Join(err, l.unwatch())
 	l.unwatch = nil
+	l.logAttrs(context.Background(), slog.LevelDebug, "config watch stopped")
 	return err
 }
 
+// Dependencies returns the additional files the current configuration was
+// read from, as reported by the load function with ReportDependencies,
+// for example secret files referenced by NAME_FILE environment variables
+// with the loaders of the loadenv package.
+//
+// While the Loader is watching, changes to the dependencies invalidate the
+// configuration just like changes to the file itself.
+//
+// Thread-safe. Safe to call on a nil Loader (returns nil).
+func (l *Loader[T]) Dependencies() []fs.File {
+	if l == nil {
+		return nil
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return slices.Clone(l.dependencies)
+}
+
+// setDependencies replaces the dependencies and, while watching, their
+// watches. The caller must hold l.mtx.
+func (l *Loader[T]) setDependencies(dependencies []fs.File) error {
+	dependencies = slices.Compact(slices.Sorted(slices.Values(dependencies)))
+	if slices.Equal(dependencies, l.dependencies) && (l.unwatch == nil || l.unwatchDependencies != nil || len(dependencies) == 0) {
+		return nil
+	}
+	var err error
+	if l.unwatchDependencies != nil {
+		err = l.unwatchDependencies()
+		l.unwatchDependencies = nil
+	}
+	l.dependencies = dependencies
+	if l.unwatch == nil {
+		return err
+	}
+	return errors.Join(err, l.watchDependencies())
+}
+
+// watchDependencies watches the directories of the dependencies and
+// invalidates the configuration when a dependency is created or written.
+//
+// Entries starting with ".." also invalidate, because Kubernetes rotates
+// mounted secrets and config maps by atomically replacing a "..data"
+// symlink in the directory instead of writing the files themselves.
+// The caller must hold l.mtx.
+func (l *Loader[T]) watchDependencies() error {
+	dirs := make(map[fs.File][]fs.File)
+	for _, dep := range l.dependencies {
+		dirs[dep.Dir()] = append(dirs[dep.Dir()], dep)
+	}
+	var cancels []func() error
+	unwatchAll := func() error {
+		var err error
+		for _, cancel := range cancels {
+			err = errors.Join(err, cancel())
+		}
+		return err
+	}
+	for dir, deps := range dirs {
+		cancel, err := dir.Watch(func(f fs.File, e fs.Event) {
+			changed := slices.Contains(deps, f) && (e.HasCreate() || e.HasWrite())
+			if changed || strings.HasPrefix(f.Name(), "..") {
+				l.fileChanged()
+			}
+		})
+		if err != nil {
+			return errors.Join(fmt.Errorf("watch config dependency error: %w", err), unwatchAll())
+		}
+		cancels = append(cancels, cancel)
+	}
+	if len(cancels) > 0 {
+		l.unwatchDependencies = unwatchAll
+	}
+	return nil
+}
+
 // Load returns the current configuration, loading it from the file if necessary.
 //
 // Behavior:
@@ -532,32 +722,97 @@ func (l *Loader[T]) Unwatch() error {
 //	// File changes invalidate, causing next Load() to reload
 //	config2, _ := loader.Load() // Reloads if file changed
 func (l *Loader[T]) Load() (T, error) {
+	return l.LoadContext(context.Background())
+}
+
+// LoadContext is like Load but passes ctx to the load function, so loading
+// from a slow remote go-fs file system can be canceled. Load functions
+// without context, passed to NewLoader or adapted with ContextLoad,
+// are only canceled before they start.
+//
+// Safe to call on a nil Loader (returns an error). Thread-safe.
+func (l *Loader[T]) LoadContext(ctx context.Context) (T, error) {
 	if l == nil {
 		return *new(T), errors.New("<nil> Loader")
 	}
 	l.mtx.Lock()
-	defer l.mtx.Unlock()
+	defer l.unlock()
 
+	if l.closed {
+		return l.config, ErrClosed
+	}
 	if l.loaded {
 		return l.config, nil
 	}
 
-	config, err := l.load(l.file)
+	config, report, err := l.readFile(ctx)
 	if err != nil {
+		l.loadFailed(err)
 		if l.onError != nil {
 			return l.onError(err), err
 		}
 		return l.config, err // Return last known config
 	}
 	if l.onLoad != nil {
-		l.config = l.onLoad(config)
-	} else {
-		l.config = config
+		config = l.onLoad(config)
 	}
+	l.setConfig(config)
+	l.sources = report.sources
+	l.interpolated = report.interpolated
 	l.loaded = true
+	l.loadSucceeded()
+	// A dependency that can't be watched doesn't fail the load,
+	// watching it is retried with the next load.
+	_ = l.setDependencies(report.dependencies)
 	return l.config, nil
 }
 
+// readFile calls the load function with the file and returns what it
+// reported with ReportSources and ReportDependencies.
+// The caller must hold l.mtx.
+func (l *Loader[T]) readFile(ctx context.Context) (config T, report *loadReport, err error) {
+	start := time.Now()
+	report, stop := startLoadReport(l.file)
+	config, err = l.load(ctx, l.file)
+	stop()
+	if err != nil {
+		l.observeLoad(ctx, start, "", err)
+		return *new(T), nil, err
+	}
+	l.unreportedContent = !report.contentReported
+	l.observeLoad(ctx, start, l.metricsVersion(ctx, report), nil)
+	return config, report, nil
+}
+
+// Sources returns where the values of the current configuration came from,
+// as reported by the load function with ReportSources, for example by the
+// loaders of the loadenv package which report the fields overridden by
+// environment variables.
+//
+// The returned map describes the last successful load from the file
+// and is nil if nothing was reported or no load has succeeded yet.
+// Fields without an entry were read from the file.
+// Mutate and Set keep the sources of the last load, except that Mutate
+// reading the file anew replaces them with that read's sources.
+//
+// Thread-safe. Safe to call on a nil Loader (returns nil).
+//
+// Example:
+//
+//	loader := dynconfig.MustLoadAndWatch("config.json", loadenv.LoadEnvJSON[*Config], nil, nil, nil, nil)
+//	for path, source := range loader.Sources() {
+//	    log.Printf("%s from %s", path, source) // Port from env APP_PORT
+//	}
+func (l *Loader[T]) Sources() Sources {
+	if l == nil {
+		return nil
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return maps.Clone(l.sources)
+}
+
 // Get returns the current configuration, loading it from the file if necessary.
 //
 // This is a convenience method that wraps Load() and discards the error.
@@ -602,37 +857,42 @@ func (l *Loader[T]) Get() T {
 // rely on the cache reflecting the latest file (for example when no watcher is
 // running) and need a lost-update-free read-modify-write.
 //
-// For files on the local file system Mutate acquires an exclusive operating-system
-// advisory lock (flock) on the file's parent directory and holds it for the
-// whole read-modify-write cycle. The mutated value is written to a temporary
-// file in the same directory and then atomically renamed over the target, so a
-// reader (or another process) never observes a partially written file and a
-// crash leaves the original file intact. While the lock is held, any other
-// process that also writes a file in that directory through Mutate or Set blocks
-// until this call completes, so concurrent processes cannot interleave their
-// writes. Within the process the Loader's mutex additionally serializes Mutate
-// against Load, Get, Set, and Invalidate.
-//
-// The lock is taken on the directory rather than the file itself because the
-// atomic rename replaces the file's inode; a lock held on the old inode would
-// stop excluding a process that opened the new one. A consequence is that Mutate
-// and Set calls on different files in the same directory also serialize against
-// each other.
+// For files on the local file system Mutate acquires an exclusive
+// operating-system advisory lock (flock) and holds it for the whole
+// read-modify-write cycle. By default the lock is on the file's parent
+// directory; with WithLockStrategy(LockFile) it is on a sidecar lock file.
+// The mutated value is written to a temporary file in the same directory and
+// then atomically renamed over the target, so a reader (or another process)
+// never observes a partially written file and a crash leaves the original file
+// intact. While the lock is held, any other process that writes a file in that
+// directory through Mutate or Set (with LockFile only this file) blocks until
+// this call completes, so concurrent processes cannot interleave their writes.
+// Within the process the Loader's mutex additionally serializes Mutate against
+// Load, Get, Set, and Invalidate.
+//
+// The lock is never taken on the file itself because the atomic rename
+// replaces the file's inode; a lock held on the old inode would stop excluding
+// a process that opened the new one. With the default LockDirectory strategy
+// a consequence is that Mutate and Set calls on different files in the same
+// directory also serialize against each other. The LockFile strategy avoids
+// that by locking a sidecar file like .config.json.lock instead, which the
+// rename doesn't replace either.
 //
 // The sequence is:
 //
-//  1. For a local file, open the parent directory and acquire an exclusive
-//     OS-level lock on it.
+//  1. For a local file, open the parent directory (or the lock file) and
+//     acquire an exclusive OS-level lock on it.
 //  2. Obtain the current configuration: when reload is false and a valid cached
 //     value is available it is used as-is; otherwise the on-disk content is
 //     parsed with the Loader's load function.
 //  3. Call mutate with that value and use its returned value as the mutated
 //     configuration.
 //  4. Write the mutated value to a temporary file using the save function
-//     passed to the constructor, then atomically rename it over the target.
+//     passed to the constructor, flush it to stable storage, then atomically
+//     rename it over the target and flush the directory (see WithDurableWrites).
 //  5. Cache the mutated value so the next Get or Load returns it without
 //     re-reading the file.
-//  6. Release the lock and close the directory.
+//  6. Release the lock and close the directory (or the lock file).
 //
 // On failure the configuration file is left untouched: a read or mutate error
 // aborts before anything is written, and a save error discards the temporary
@@ -650,11 +910,14 @@ func (l *Loader[T]) Get() T {
 // Requirements and caveats:
 //   - mutate must be non-nil and a save function must have been passed to the
 //     constructor (NewLoader, LoadAndWatch or MustLoadAndWatch).
+//   - A configuration loaded with Interpolate is not saved, Mutate and
+//     Set return ErrInterpolated, so the expanded values of ${...} references
+//     don't overwrite the references in the file.
 //   - mutate runs while the lock is held, so keep it a fast, pure in-memory
 //     transform. Slow work inside it (network calls, disk I/O, blocking) holds
-//     the directory lock for that whole time, blocking other processes' Mutate
-//     and Set on any file in the same directory as well as every in-process
-//     Loader operation. Do expensive work before calling Mutate.
+//     the lock for that whole time, blocking other processes' Mutate and Set
+//     on any file in the same directory (only this file with LockFile) as well
+//     as every in-process Loader operation. Do expensive work before calling Mutate.
 //   - With reload false, Mutate reuses the cached configuration, so to be sure it
 //     sees a write made by another process either pass reload true, run a watcher
 //     (which invalidates the cache when the file changes), or call Invalidate
@@ -665,15 +928,17 @@ func (l *Loader[T]) Get() T {
 //     rename, protected only by the Loader's in-process mutex (so it is not
 //     safe against other processes mutating the same file).
 //   - The atomic write path needs write permission on the parent directory, not
-//     just on the file, because it locks the directory and creates a temporary
-//     file there. A file that is writable in a directory you cannot write to
-//     therefore fails on the atomic path, where a plain overwrite would succeed.
+//     just on the file, because it creates a temporary file there (and with
+//     LockFile the lock file). A file that is writable in a directory you
+//     cannot write to therefore fails on the atomic path, where a plain
+//     overwrite would succeed.
 //   - When the file is a symbolic link, the atomic rename replaces the link
 //     itself with a regular file (carrying the link target's permission bits),
 //     rather than writing through it to the target. The non-local and
 //     no-flock fallback paths overwrite in place and so follow the link instead.
 //   - The lock is advisory: it only excludes other processes that cooperate by
-//     locking the same directory (as Mutate and Set do). It does not protect
+//     locking the same directory or lock file (as Mutate and Set do with the
+//     same LockStrategy). It does not protect
 //     against writers that ignore the lock.
 //   - Exclusive locking is implemented with flock on Unix. On platforms without
 //     a supported implementation Mutate likewise falls back to an in-place
@@ -702,52 +967,101 @@ func (l *Loader[T]) Get() T {
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
-func (l *Loader[T]) Mutate(reload bool, mutate func(config T) (T, error)) (err error) {
+func (l *Loader[T]) Mutate(reload bool, mutate func(config T) (T, error)) error {
+	return l.MutateContext(context.Background(), reload, mutate)
+}
+
+// MutateContext is like Mutate but gives up waiting for the in-process mutex
+// or the OS-level write lock when ctx ends, returning a *LockTimeoutError
+// that matches ErrLockTimeout with errors.Is and names the contended path.
+// While another process holds the lock, acquisition is retried with a
+// non-blocking flock and exponential backoff of up to 50 milliseconds.
+//
+// ctx is also passed to the load and save functions of a Loader created
+// with NewLoaderContext or LoadAndWatchContext, so they can cancel reading
+// and writing a remote file. On the atomic path for local files a canceled
+// save only discards the temporary file, so the configuration file is never
+// left half written because of a cancellation. On the in-place fallback
+// path it depends on the save function and the go-fs file system.
+//
+// Safe to call on a nil Loader (returns an error). Thread-safe.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	err := loader.MutateContext(ctx, false, func(c Counter) (Counter, error) {
+//	    c.Value++
+//	    return c, nil
+//	})
+//	if errors.Is(err, dynconfig.ErrLockTimeout) {
+//	    log.Printf("counter is locked by another writer: %v", err)
+//	}
+func (l *Loader[T]) MutateContext(ctx context.Context, reload bool, mutate func(config T) (T, error)) (err error) {
 	if l == nil {
 		return errors.New("<nil> Loader")
 	}
 	if mutate == nil {
 		return errors.New("Mutate() mutate function must not be nil")
 	}
+	start := time.Now()
+	var version Version
+	defer func() { l.observeWrite(ctx, "Mutate", start, version, err) }()
 
-	l.mtx.Lock()
-	defer l.mtx.Unlock()
+	e := l.lockMutex(ctx)
+	if e != nil {
+		return fmt.Errorf("Mutate() %w", e)
+	}
+	defer l.unlock()
 
+	if l.closed {
+		return fmt.Errorf("Mutate() %w", ErrClosed)
+	}
 	if l.save == nil {
 		return errors.New("Mutate() requires a save function passed to the constructor")
 	}
 
-	atomic, localPath, release, e := l.lockForWrite()
+	atomic, localPath, release, e := l.lockForWrite(ctx)
 	if e != nil {
 		return fmt.Errorf("Mutate() %w", e)
 	}
 	defer func() { err = errors.Join(err, release()) }()
+	l.observeLockWait(ctx, "Mutate", start)
 
 	// Reuse the cached configuration when it is valid; read from disk when reload
 	// is requested or the cache is empty or has been invalidated.
-	config := l.config
+	config, sources, dependencies, interpolated := l.config, l.sources, l.dependencies, l.interpolated
 	if reload || !l.loaded {
-		config, e = l.load(l.file)
+		var report *loadReport
+		config, report, e = l.readFile(ctx)
 		if e != nil {
 			return fmt.Errorf("Mutate() read error: %w", e)
 		}
+		sources, dependencies, interpolated = report.sources, report.dependencies, report.interpolated
+	}
+	if interpolated {
+		return fmt.Errorf("Mutate() %w: %s", ErrInterpolated, l.file)
 	}
 	config, e = mutate(config)
 	if e != nil {
 		return fmt.Errorf("Mutate() mutate error: %w", e)
 	}
-	e = l.writeConfig(atomic, localPath, config)
+	e = l.writeConfig(ctx, atomic, localPath, config)
 	if e != nil {
 		return fmt.Errorf("Mutate() save error: %w", e)
 	}
+	version = l.metricsVersion(ctx, nil)
 
 	// Cache the mutated value directly so it is immediately visible without
 	// re-reading the file. Mutate deliberately does NOT apply onLoad: the mutate
 	// function is handed, and returns, exactly the value Get exposes, so there is
 	// nothing for onLoad to transform (see the doc comment). A file watcher, if
 	// active, will additionally invalidate after observing the write.
-	l.config = config
+	l.setConfig(config)
+	l.sources = sources
 	l.loaded = true
+	l.status.degraded = false
+	_ = l.setDependencies(dependencies)
 	return nil
 }
 
@@ -761,11 +1075,11 @@ func (l *Loader[T]) Mutate(reload bool, mutate func(config T) (T, error)) (err e
 //
 // Set uses the same locking and atomic-write machinery as Mutate (see Mutate for
 // the full description): on the local file system it holds an exclusive lock on
-// the parent directory and writes through a temporary file and atomic rename, so
-// it has the same cross-process and crash safety and serializes against Mutate,
-// Set, Load, Get, and Invalidate. On non-local file systems or platforms without
-// flock it falls back to an in-place overwrite protected only by the in-process
-// mutex.
+// the parent directory, or on the lock file with WithLockStrategy(LockFile),
+// and writes through a temporary file and atomic rename, so it has the same
+// cross-process and crash safety and serializes against Mutate, Set, Load, Get,
+// and Invalidate. On non-local file systems or platforms without flock it falls
+// back to an in-place overwrite protected only by the in-process mutex.
 //
 // Because Set does not read the file first, it can create a new file (the parent
 // directory must exist). Like Mutate, it does not apply the onLoad callback; the
@@ -787,55 +1101,122 @@ func (l *Loader[T]) Mutate(reload bool, mutate func(config T) (T, error)) (err e
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
-func (l *Loader[T]) Set(config T) (err error) {
+func (l *Loader[T]) Set(config T) error {
+	return l.SetContext(context.Background(), config)
+}
+
+// SetContext is like Set but gives up waiting for the in-process mutex or
+// the OS-level write lock when ctx ends, returning a *LockTimeoutError that
+// matches ErrLockTimeout, and passes ctx to a context-aware save function.
+// See MutateContext.
+//
+// Safe to call on a nil Loader (returns an error). Thread-safe.
+func (l *Loader[T]) SetContext(ctx context.Context, config T) error {
+	_, err := l.set(ctx, "Set()", nil, config)
+	return err
+}
+
+// set implements SetContext and SetIfVersionContext, prefixing errors with
+// method. If expected is not nil, the file must have the expected version
+// under the write lock and the version of the written file is returned.
+func (l *Loader[T]) set(ctx context.Context, method string, expected *Version, config T) (version Version, err error) {
 	if l == nil {
-		return errors.New("<nil> Loader")
+		return "", errors.New("<nil> Loader")
 	}
+	start := time.Now()
+	defer func() { l.observeWrite(ctx, strings.TrimSuffix(method, "()"), start, version, err) }()
 
-	l.mtx.Lock()
-	defer l.mtx.Unlock()
+	e := l.lockMutex(ctx)
+	if e != nil {
+		return "", fmt.Errorf("%s %w", method, e)
+	}
+	defer l.unlock()
 
+	if l.closed {
+		return "", fmt.Errorf("%s %w", method, ErrClosed)
+	}
 	if l.save == nil {
-		return errors.New("Set() requires a save function passed to the constructor")
+		return "", fmt.Errorf("%s requires a save function passed to the constructor", method)
+	}
+	if l.interpolated {
+		return "", fmt.Errorf("%s %w: %s", method, ErrInterpolated, l.file)
 	}
 
-	atomic, localPath, release, e := l.lockForWrite()
+	atomic, localPath, release, e := l.lockForWrite(ctx)
 	if e != nil {
-		return fmt.Errorf("Set() %w", e)
+		return "", fmt.Errorf("%s %w", method, e)
 	}
 	defer func() { err = errors.Join(err, release()) }()
+	l.observeLockWait(ctx, strings.TrimSuffix(method, "()"), start)
 
-	e = l.writeConfig(atomic, localPath, config)
+	if expected != nil {
+		current, e := l.fileVersion(ctx)
+		if e != nil {
+			return "", fmt.Errorf("%s read error: %w", method, e)
+		}
+		if current != *expected {
+			return "", fmt.Errorf("%s %w: %s was modified", method, ErrConflict, l.file)
+		}
+	}
+
+	e = l.writeConfig(ctx, atomic, localPath, config)
 	if e != nil {
-		return fmt.Errorf("Set() save error: %w", e)
+		return "", fmt.Errorf("%s save error: %w", method, e)
 	}
 
 	// Cache the written value directly so it is immediately visible without
 	// re-reading the file. A file watcher, if active, will additionally
 	// invalidate after observing the write.
-	l.config = config
+	l.setConfig(config)
 	l.loaded = true
-	return nil
+	l.status.degraded = false
+
+	// Still holding the locks, so no cooperating writer changed the file.
+	if expected == nil {
+		return l.metricsVersion(ctx, nil), nil
+	}
+	version, e = l.fileVersion(ctx)
+	if e != nil {
+		return "", fmt.Errorf("%s read error: %w", method, e)
+	}
+	return version, nil
 }
 
 // lockForWrite acquires the exclusive write lock for the configuration file when
-// it is on a lockable local file system. It reports whether the atomic write
+// it is on a lockable local file system, on its directory or lock file
+// depending on the LockStrategy. It reports whether the atomic write
 // path applies and the local path (empty for non-local file systems), and
 // returns a release function that unlocks and closes the lock handle (a no-op
-// when no lock was taken). The caller must hold l.mtx.
-func (l *Loader[T]) lockForWrite() (atomic bool, localPath string, release func() error, err error) {
+// when no lock was taken). While another process holds the lock it waits
+// until ctx ends, returning a *LockTimeoutError. The caller must hold l.mtx.
+func (l *Loader[T]) lockForWrite(ctx context.Context) (atomic bool, localPath string, release func() error, err error) {
 	localPath = l.file.LocalPath()
 	if localPath == "" || !fsLockSupported {
 		// Non-local go-fs file system or a platform without flock support: no OS
 		// lock, the caller is protected only by the in-process mutex.
 		return false, localPath, func() error { return nil }, nil
 	}
-	// Lock the parent directory, whose inode the atomic rename never replaces.
-	d, err := os.Open(filepath.Dir(localPath))
-	if err != nil {
-		return false, "", nil, fmt.Errorf("open directory for locking error: %w", err)
+	var d *os.File
+	switch l.opts.lockStrategy {
+	case LockFile:
+		// Lock a sidecar file, which the atomic rename never replaces.
+		dir, name := filepath.Split(localPath)
+		d, err = os.OpenFile(filepath.Join(dir, "."+name+".lock"), os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			return false, "", nil, fmt.Errorf("open lock file error: %w", err)
+		}
+	default:
+		// Lock the parent directory, whose inode the atomic rename never replaces.
+		d, err = os.Open(filepath.Dir(localPath))
+		if err != nil {
+			return false, "", nil, fmt.Errorf("open directory for locking error: %w", err)
+		}
+	}
+	if ctx.Done() == nil {
+		err = lockFileExclusive(d)
+	} else {
+		err = pollLock(ctx, d.Name(), func() (bool, error) { return tryLockFileExclusive(d) })
 	}
-	err = lockFileExclusive(d)
 	if err != nil {
 		return false, "", nil, errors.Join(
 			fmt.Errorf("lock error: %w", err),
@@ -851,19 +1232,32 @@ func (l *Loader[T]) lockForWrite() (atomic bool, localPath string, release func(
 // and rename on a lockable local file system, or with a plain in-place overwrite
 // otherwise. The caller must hold l.mtx and, on the atomic path, the directory
 // lock from lockForWrite.
-func (l *Loader[T]) writeConfig(atomic bool, localPath string, config T) error {
+func (l *Loader[T]) writeConfig(ctx context.Context, atomic bool, localPath string, config T) error {
 	if atomic {
-		return l.saveAtomic(localPath, config)
+		return l.saveAtomic(ctx, localPath, config)
 	}
-	return l.save(l.file, config)
+	return l.save(ctx, l.file, config)
 }
 
 // saveAtomic writes config to a temporary file in the same directory as
 // localPath using the Loader's save function, then atomically renames it over
 // the target. A reader or another writer therefore never observes a partially
 // written file, and a save error or crash leaves the original file intact.
-// The caller must hold the directory lock.
-func (l *Loader[T]) saveAtomic(localPath string, config T) (err error) {
+// The caller must hold the write lock.
+func (l *Loader[T]) saveAtomic(ctx context.Context, localPath string, config T) error {
+	return l.replaceAtomic(localPath, func(tmpPath string) error {
+		return l.save(ctx, fs.File(tmpPath), config)
+	})
+}
+
+// replaceAtomic calls write with the path of a new temporary file in the same
+// directory as localPath, backs up the current file if configured, and then
+// atomically renames the temporary file over the target. With durable writes
+// the temporary file is synced before and the directory after the rename.
+// The caller must hold the write lock.
+func (l *Loader[T]) replaceAtomic(localPath string, write func(tmpPath string) error) (err error) {
+	ops := l.fileOps()
+	durable := !l.opts.nonDurableWrites
 	dir, name := filepath.Split(localPath)
 	tmp, err := os.CreateTemp(dir, "."+name+".tmp-*")
 	if err != nil {
@@ -883,7 +1277,7 @@ func (l *Loader[T]) saveAtomic(localPath string, config T) (err error) {
 		}
 	}()
 
-	err = l.save(fs.File(tmpPath), config)
+	err = write(tmpPath)
 	if err != nil {
 		return err
 	}
@@ -894,10 +1288,28 @@ func (l *Loader[T]) saveAtomic(localPath string, config T) (err error) {
 			return err
 		}
 	}
-	err = os.Rename(tmpPath, localPath)
+	if durable {
+		// Persist the data before the rename can be persisted
+		err = ops.syncFile(tmpPath)
+		if err != nil {
+			return fmt.Errorf("sync temporary file error: %w", err)
+		}
+	}
+	err = l.backup(localPath)
+	if err != nil {
+		return err
+	}
+	err = ops.rename(tmpPath, localPath)
 	if err != nil {
 		return err
 	}
 	renamed = true
+	if durable {
+		// Persist the rename and any backup links
+		err = ops.syncDir(filepath.Clean(dir))
+		if err != nil {
+			return fmt.Errorf("sync directory error: %w", err)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,53 @@
+package dynconfig
+
+// LockStrategy selects what Set, Mutate and Rollback lock for exclusive
+// cross-process access to a local configuration file.
+type LockStrategy int
+
+const (
+	// LockDirectory locks the parent directory of the configuration file.
+	// It is the default and needs no extra file, but serializes writes to
+	// all files in the same directory.
+	LockDirectory LockStrategy = iota
+
+	// LockFile locks a sidecar lock file next to the configuration file,
+	// named like ".config.json.lock", so writes to different files in the
+	// same directory don't block each other. The lock file survives the
+	// atomic rename because only the configuration file is replaced.
+	// It is created on the first write and never deleted, because deleting
+	// it could let two processes lock different lock files for the same
+	// configuration file.
+	LockFile
+)
+
+// String implements fmt.Stringer.
+func (s LockStrategy) String() string {
+	switch s {
+	case LockDirectory:
+		return "LockDirectory"
+	case LockFile:
+		return "LockFile"
+	}
+	return "LockStrategy(invalid)"
+}
+
+// WithLockStrategy selects what the Loader locks when writing the
+// configuration file on the atomic write path, LockDirectory by default.
+//
+// All processes writing the same file must use the same strategy,
+// because a directory lock and a lock file don't exclude each other.
+//
+// Example with many tenant configuration files in one directory:
+//
+//	loader := dynconfig.MustLoadAndWatch(
+//	    "tenants/acme.json",
+//	    dynconfig.LoadJSON[Tenant],
+//	    dynconfig.SaveJSON[Tenant]("  "),
+//	    nil, nil, nil,
+//	    dynconfig.WithLockStrategy(dynconfig.LockFile),
+//	)
+func WithLockStrategy(strategy LockStrategy) Option {
+	return func(o *loaderOptions) {
+		o.lockStrategy = strategy
+	}
+}
@@ -0,0 +1,182 @@
+package dynconfig
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ungerik/go-fs"
+)
+
+// TestLockFile_MultiProcess runs TestMutate_MultiProcess with the LockFile
+// strategy: N child processes increment the shared counter once each, so a
+// lost update would mean the lock file failed to exclude another process
+// across the atomic renames.
+func TestLockFile_MultiProcess(t *testing.T) {
+	if path := os.Getenv("DYNCONFIG_LOCKFILE_WORKER"); path != "" {
+		// Child process: increment once and exit before the parent body runs.
+		l := NewLoader(fs.File(path), LoadJSON[counter], SaveJSON[counter](), nil, nil, nil, WithLockStrategy(LockFile))
+		if err := l.Mutate(false, func(c counter) (counter, error) { c.Value++; return c, nil }); err != nil {
+			fmt.Fprintln(os.Stderr, "worker Mutate:", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if !fsLockSupported {
+		t.Skip("requires OS file locking for the atomic write path")
+	}
+
+	file := writeTempJSON(t, "counter.json", `{"value": 0}`)
+	localPath := file.LocalPath()
+
+	const n = 15
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for range n {
+		go func() {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=^TestLockFile_MultiProcess$")
+			cmd.Env = append(os.Environ(), "DYNCONFIG_LOCKFILE_WORKER="+localPath)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Errorf("worker process failed: %v\n%s", err, out)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := readValue(t, localPath); got != n {
+		t.Errorf("on-disk value = %d, want %d (lost updates => lock file not working cross-process)", got, n)
+	}
+	entries, err := os.ReadDir(filepath.Dir(localPath))
+	if err != nil {
+		t.Fatalf("ReadDir: %s", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{".counter.json.lock", "counter.json"}; !slices.Equal(names, want) {
+		t.Errorf("directory entries = %v, want %v", names, want)
+	}
+}
+
+// TestLockFile_OtherFileNotBlocked holds a Mutate on a.json and verifies that
+// a child process can Set b.json in the same directory with the LockFile
+// strategy, where the directory lock would block it.
+func TestLockFile_OtherFileNotBlocked(t *testing.T) {
+	if path := os.Getenv("DYNCONFIG_LOCKFILE_SET"); path != "" {
+		// Child process: write the other file and exit before the parent body runs.
+		l := NewLoader(fs.File(path), LoadJSON[counter], SaveJSON[counter](), nil, nil, nil, WithLockStrategy(LockFile))
+		if err := l.Set(counter{Value: 2}); err != nil {
+			fmt.Fprintln(os.Stderr, "worker Set:", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if !fsLockSupported {
+		t.Skip("requires OS file locking for the atomic write path")
+	}
+
+	a := writeTempJSON(t, "a.json", `{"value": 0}`)
+	b := fs.File(filepath.Join(a.Dir().LocalPath(), "b.json"))
+	err := b.WriteAllString(`{"value": 0}`)
+	if err != nil {
+		t.Fatalf("write b.json: %s", err)
+	}
+
+	locked := make(chan struct{})
+	unlock := make(chan struct{})
+	mutateErr := make(chan error, 1)
+	loader := NewLoader(a, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil, WithLockStrategy(LockFile))
+	go func() {
+		mutateErr <- loader.Mutate(false, func(c counter) (counter, error) {
+			close(locked)
+			<-unlock
+			c.Value = 1
+			return c, nil
+		})
+	}()
+	<-locked
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLockFile_OtherFileNotBlocked$")
+	cmd.Env = append(os.Environ(), "DYNCONFIG_LOCKFILE_SET="+b.LocalPath())
+	done := make(chan error, 1)
+	var out []byte
+	go func() {
+		var e error
+		out, e = cmd.CombinedOutput()
+		done <- e
+	}()
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Errorf("worker process failed: %v\n%s", err, out)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Set on b.json blocked by Mutate on a.json")
+	}
+
+	close(unlock)
+	if err := <-mutateErr; err != nil {
+		t.Fatalf("Mutate: %s", err)
+	}
+	if got := readValue(t, a.LocalPath()); got != 1 {
+		t.Errorf("a.json value = %d, want 1", got)
+	}
+	if got := readValue(t, b.LocalPath()); got != 2 {
+		t.Errorf("b.json value = %d, want 2", got)
+	}
+}
+
+// TestLockFile_SameFileBlocked verifies that two Loaders of the same file
+// with the LockFile strategy exclude each other.
+func TestLockFile_SameFileBlocked(t *testing.T) {
+	if !fsLockSupported {
+		t.Skip("requires OS file locking for the atomic write path")
+	}
+
+	file := writeTempJSON(t, "counter.json", `{"value": 0}`)
+	first := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil, WithLockStrategy(LockFile))
+	second := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil, WithLockStrategy(LockFile))
+
+	locked := make(chan struct{})
+	unlock := make(chan struct{})
+	mutateErr := make(chan error, 1)
+	go func() {
+		mutateErr <- first.Mutate(false, func(c counter) (counter, error) {
+			close(locked)
+			<-unlock
+			c.Value++
+			return c, nil
+		})
+	}()
+	<-locked
+
+	setErr := make(chan error, 1)
+	go func() {
+		setErr <- second.Set(counter{Value: 10})
+	}()
+	select {
+	case err := <-setErr:
+		t.Fatalf("Set completed while the lock file was held: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(unlock)
+	if err := <-mutateErr; err != nil {
+		t.Fatalf("Mutate: %s", err)
+	}
+	if err := <-setErr; err != nil {
+		t.Fatalf("Set: %s", err)
+	}
+	if got := readValue(t, file.LocalPath()); got != 10 {
+		t.Errorf("on-disk value = %d, want 10 written after the Mutate", got)
+	}
+}
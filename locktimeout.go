@@ -0,0 +1,86 @@
+package dynconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLockTimeout is matched by errors.Is for a *LockTimeoutError returned by
+// MutateContext and SetContext when the context ends before the write lock
+// could be acquired.
+var ErrLockTimeout = errors.New("lock timeout")
+
+// LockTimeoutError is returned by MutateContext and SetContext when the
+// context ends before the write lock could be acquired.
+//
+// Example:
+//
+//	var lockErr *dynconfig.LockTimeoutError
+//	if errors.As(err, &lockErr) {
+//	    log.Printf("%s is locked by another writer", lockErr.Path)
+//	}
+type LockTimeoutError struct {
+	// Path of the contended lock: the directory or lock file for the
+	// OS-level lock, or the configuration file when another goroutine
+	// holds the Loader's in-process mutex.
+	Path string
+	// Err is the error of the context, context.DeadlineExceeded or
+	// context.Canceled.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *LockTimeoutError) Error() string {
+	return fmt.Sprintf("lock timeout on %s: %s", e.Path, e.Err)
+}
+
+// Is makes errors.Is(err, ErrLockTimeout) true.
+func (e *LockTimeoutError) Is(target error) bool {
+	return target == ErrLockTimeout
+}
+
+// Unwrap returns the error of the context.
+func (e *LockTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+const (
+	// lockPollMin is the first delay between attempts to acquire a lock.
+	lockPollMin = time.Millisecond
+	// lockPollMax is the longest delay between attempts to acquire a lock.
+	lockPollMax = 50 * time.Millisecond
+)
+
+// pollLock calls tryLock with exponential backoff until it succeeds,
+// fails, or ctx ends, in which case a *LockTimeoutError for path is returned.
+func pollLock(ctx context.Context, path string, tryLock func() (bool, error)) error {
+	delay := lockPollMin
+	for {
+		locked, err := tryLock()
+		if err != nil || locked {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &LockTimeoutError{Path: path, Err: ctx.Err()}
+		case <-timer.C:
+		}
+		delay = min(delay*2, lockPollMax)
+	}
+}
+
+// lockMutex locks l.mtx, giving up with a *LockTimeoutError when ctx ends.
+// A context that can't end locks without polling.
+func (l *Loader[T]) lockMutex(ctx context.Context) error {
+	if ctx.Done() == nil {
+		l.mtx.Lock()
+		return nil
+	}
+	return pollLock(ctx, string(l.file), func() (bool, error) {
+		return l.mtx.TryLock(), nil
+	})
+}
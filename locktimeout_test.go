@@ -0,0 +1,156 @@
+package dynconfig
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// holdMutate starts a Mutate on loader that holds its locks until the
+// returned release function is called, which waits for the Mutate to
+// complete and returns its error.
+func holdMutate(t *testing.T, loader *Loader[counter], value int) (release func() error) {
+	t.Helper()
+	locked := make(chan struct{})
+	unlock := make(chan struct{})
+	mutateErr := make(chan error, 1)
+	go func() {
+		mutateErr <- loader.Mutate(false, func(c counter) (counter, error) {
+			close(locked)
+			<-unlock
+			c.Value = value
+			return c, nil
+		})
+	}()
+	<-locked
+	return func() error {
+		close(unlock)
+		return <-mutateErr
+	}
+}
+
+func TestSetContext_LockTimeout(t *testing.T) {
+	if !fsLockSupported {
+		t.Skip("requires OS file locking for the atomic write path")
+	}
+
+	tests := []struct {
+		name     string
+		strategy LockStrategy
+		lockPath func(localPath string) string
+	}{
+		{
+			name:     "LockDirectory",
+			strategy: LockDirectory,
+			lockPath: filepath.Dir,
+		},
+		{
+			name:     "LockFile",
+			strategy: LockFile,
+			lockPath: func(localPath string) string {
+				return filepath.Join(filepath.Dir(localPath), ".counter.json.lock")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := writeTempJSON(t, "counter.json", `{"value": 0}`)
+			holder := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil, WithLockStrategy(tt.strategy))
+			waiter := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil, WithLockStrategy(tt.strategy))
+			release := holdMutate(t, holder, 1)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			err := waiter.SetContext(ctx, counter{Value: 2})
+
+			if !errors.Is(err, ErrLockTimeout) {
+				t.Errorf("SetContext error = %v, want ErrLockTimeout", err)
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("SetContext error = %v, want context.DeadlineExceeded", err)
+			}
+			var lockErr *LockTimeoutError
+			if !errors.As(err, &lockErr) {
+				t.Fatalf("SetContext error = %v, want *LockTimeoutError", err)
+			}
+			if want := tt.lockPath(file.LocalPath()); lockErr.Path != want {
+				t.Errorf("LockTimeoutError.Path = %q, want %q", lockErr.Path, want)
+			}
+
+			if err := release(); err != nil {
+				t.Fatalf("Mutate: %s", err)
+			}
+			if got := readValue(t, file.LocalPath()); got != 1 {
+				t.Errorf("on-disk value = %d, want 1 from the holder only", got)
+			}
+			if err := waiter.SetContext(context.Background(), counter{Value: 3}); err != nil {
+				t.Fatalf("SetContext after release: %s", err)
+			}
+			if got := readValue(t, file.LocalPath()); got != 3 {
+				t.Errorf("on-disk value = %d, want 3", got)
+			}
+		})
+	}
+}
+
+func TestMutateContext_MutexTimeout(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value": 0}`)
+	loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+	release := holdMutate(t, loader, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := loader.MutateContext(ctx, false, func(c counter) (counter, error) {
+		t.Error("mutate called without the lock")
+		return c, nil
+	})
+
+	var lockErr *LockTimeoutError
+	if !errors.As(err, &lockErr) {
+		t.Fatalf("MutateContext error = %v, want *LockTimeoutError", err)
+	}
+	if !errors.Is(err, ErrLockTimeout) || !errors.Is(err, context.Canceled) {
+		t.Errorf("MutateContext error = %v, want ErrLockTimeout and context.Canceled", err)
+	}
+	if lockErr.Path != string(file) {
+		t.Errorf("LockTimeoutError.Path = %q, want %q", lockErr.Path, file)
+	}
+	if err := release(); err != nil {
+		t.Fatalf("Mutate: %s", err)
+	}
+}
+
+func TestMutateContext_WaitsForRelease(t *testing.T) {
+	if !fsLockSupported {
+		t.Skip("requires OS file locking for the atomic write path")
+	}
+
+	file := writeTempJSON(t, "counter.json", `{"value": 0}`)
+	holder := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+	waiter := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+	release := holdMutate(t, holder, 10)
+
+	released := make(chan error, 1)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		released <- release()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := waiter.MutateContext(ctx, true, func(c counter) (counter, error) {
+		c.Value++
+		return c, nil
+	})
+	if err != nil {
+		t.Fatalf("MutateContext: %s", err)
+	}
+	if err := <-released; err != nil {
+		t.Fatalf("Mutate: %s", err)
+	}
+	if got := readValue(t, file.LocalPath()); got != 11 {
+		t.Errorf("on-disk value = %d, want 11 read after the holder's write", got)
+	}
+}
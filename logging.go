@@ -0,0 +1,104 @@
+package dynconfig
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"time"
+)
+
+// WithLogger makes the Loader log its activity to logger, so diagnostics
+// don't have to be written in the onLoad, onError and onInvalidate callbacks.
+//
+// Every record has the attributes "file" with the configuration file and
+// "type" with the configuration type. Logged are:
+//   - Debug: watch start and stop, invalidations, and the time waited
+//     for the write lock as "wait"
+//   - Info: successful loads and writes with their "duration"
+//   - Warn: writes that gave up waiting for the write lock
+//   - Error: failed loads and writes with the "error"
+//
+// Writes have a "method" attribute with the name of the Loader method,
+// like "Set" or "Mutate".
+//
+// Example:
+//
+//	loader := dynconfig.MustLoadAndWatch(
+//	    "config.json",
+//	    dynconfig.LoadJSON[Config],
+//	    nil, nil, nil, nil,
+//	    dynconfig.WithLogger(slog.Default()),
+//	)
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *loaderOptions) {
+		o.logger = logger
+	}
+}
+
+// newLoaderLogger returns the logger of the options with the attributes
+// of a Loader for file and the type T, or nil if no logger is set.
+func newLoaderLogger[T any](o loaderOptions, file string) *slog.Logger {
+	if o.logger == nil {
+		return nil
+	}
+	return o.logger.With(
+		slog.String("file", file),
+		slog.String("type", reflect.TypeFor[T]().String()),
+	)
+}
+
+// logAttrs logs msg with attrs if the Loader has a logger.
+func (l *Loader[T]) logAttrs(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	if l.logger == nil {
+		return
+	}
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// logLoad logs the result of a load that took duration.
+func (l *Loader[T]) logLoad(ctx context.Context, duration time.Duration, err error) {
+	if err != nil {
+		l.logAttrs(ctx, slog.LevelError, "config load failed",
+			slog.Duration("duration", duration),
+			slog.Any("error", err),
+		)
+		return
+	}
+	l.logAttrs(ctx, slog.LevelInfo, "config loaded",
+		slog.Duration("duration", duration),
+	)
+}
+
+// logWrite logs the result of the write method that took duration.
+func (l *Loader[T]) logWrite(ctx context.Context, method string, duration time.Duration, err error) {
+	var timeout *LockTimeoutError
+	switch {
+	case err == nil:
+		l.logAttrs(ctx, slog.LevelInfo, "config written",
+			slog.String("method", method),
+			slog.Duration("duration", duration),
+		)
+	case errors.As(err, &timeout):
+		l.logAttrs(ctx, slog.LevelWarn, "config write lock not acquired",
+			slog.String("method", method),
+			slog.String("lock", timeout.Path),
+			slog.Duration("wait", duration),
+			slog.Any("error", err),
+		)
+	default:
+		l.logAttrs(ctx, slog.LevelError, "config write failed",
+			slog.String("method", method),
+			slog.Duration("duration", duration),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// logLockWait logs the time the write method waited for the write lock.
+func (l *Loader[T]) logLockWait(ctx context.Context, method string, wait time.Duration) {
+	l.logAttrs(ctx, slog.LevelDebug, "config write lock acquired",
+		slog.String("method", method),
+		slog.Duration("wait", wait),
+	)
+}
@@ -0,0 +1,147 @@
+package dynconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// logRecorder is an io.Writer collecting the records of a slog.JSONHandler.
+type logRecorder struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (r *logRecorder) Write(p []byte) (int, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.buf.Write(p)
+}
+
+// records returns the logged records with the message msg.
+func (r *logRecorder) records(t *testing.T, msg string) []map[string]any {
+	t.Helper()
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	var records []map[string]any
+	for line := range strings.Lines(r.buf.String()) {
+		var record map[string]any
+		err := json.Unmarshal([]byte(line), &record)
+		if err != nil {
+			t.Fatalf("invalid log line %q: %s", line, err)
+		}
+		if record[slog.MessageKey] == msg {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+func newRecordingLogger() (*slog.Logger, *logRecorder) {
+	recorder := new(logRecorder)
+	handler := slog.NewJSONHandler(recorder, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return slog.New(handler), recorder
+}
+
+func TestWithLogger(t *testing.T) {
+	logger, recorder := newRecordingLogger()
+	file := writeTempJSON(t, "counter.json", `{"value": 1}`)
+	loader, err := LoadAndWatch(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil, WithLogger(logger))
+	if err != nil {
+		t.Fatalf("LoadAndWatch: %s", err)
+	}
+
+	err = loader.Set(counter{Value: 2})
+	if err != nil {
+		t.Fatalf("Set: %s", err)
+	}
+	err = file.WriteAllString(`not json`)
+	if err != nil {
+		t.Fatalf("write file: %s", err)
+	}
+	loader.Invalidate()
+	_, err = loader.Load()
+	if err == nil {
+		t.Fatal("Load of invalid JSON succeeded")
+	}
+	err = loader.Close()
+	if err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+
+	for _, msg := range []string{
+		"config watch started",
+		"config loaded",
+		"config write lock acquired",
+		"config written",
+		"config invalidated",
+		"config load failed",
+		"config watch stopped",
+		"config loader closed",
+	} {
+		records := recorder.records(t, msg)
+		if len(records) == 0 {
+			t.Errorf("no %q record logged", msg)
+			continue
+		}
+		record := records[0]
+		if record["file"] != string(file) || record["type"] != "dynconfig.counter" {
+			t.Errorf("%q record file = %v, type = %v, want %s and dynconfig.counter", msg, record["file"], record["type"], file)
+		}
+	}
+	if record := recorder.records(t, "config loaded")[0]; record["duration"] == nil {
+		t.Errorf("config loaded record without duration: %v", record)
+	}
+	if record := recorder.records(t, "config written")[0]; record["method"] != "Set" || record["level"] != "INFO" {
+		t.Errorf("config written record = %v, want method Set at INFO", record)
+	}
+	if record := recorder.records(t, "config load failed")[0]; record["error"] == nil || record["level"] != "ERROR" {
+		t.Errorf("config load failed record = %v, want error at ERROR", record)
+	}
+}
+
+func TestWithLogger_LockTimeout(t *testing.T) {
+	logger, recorder := newRecordingLogger()
+	file := writeTempJSON(t, "counter.json", `{"value": 0}`)
+	loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil, WithLogger(logger))
+	release := holdMutate(t, loader, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := loader.SetContext(ctx, counter{Value: 2})
+	if err == nil {
+		t.Fatal("SetContext acquired the held lock")
+	}
+	if err := release(); err != nil {
+		t.Fatalf("Mutate: %s", err)
+	}
+
+	records := recorder.records(t, "config write lock not acquired")
+	if len(records) != 1 {
+		t.Fatalf("got %d lock timeout records, want 1", len(records))
+	}
+	if records[0]["level"] != "WARN" || records[0]["method"] != "Set" {
+		t.Errorf("lock timeout record = %v, want method Set at WARN", records[0])
+	}
+	if len(recorder.records(t, "config written")) != 1 {
+		t.Error("want one config written record of the Mutate")
+	}
+}
+
+func TestWithoutLogger(t *testing.T) {
+	file := memFile(t, "counter.json", `{"value": 1}`)
+	loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+	if loader.logger != nil {
+		t.Fatal("Loader without WithLogger has a logger")
+	}
+	// Must not panic without a logger
+	loader.Invalidate()
+	if err := loader.Set(counter{Value: 2}); err != nil {
+		t.Fatalf("Set: %s", err)
+	}
+}
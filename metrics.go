@@ -0,0 +1,99 @@
+package dynconfig
+
+import (
+	"context"
+	"time"
+
+	"github.com/ungerik/go-fs"
+)
+
+// Metrics receives the measurements of the Loaders configured with
+// WithMetrics. Implementations must be safe for concurrent use by multiple
+// Loaders. The metrics subpackage implements Metrics with expvar and
+// Prometheus text format exporters.
+type Metrics interface {
+	// ObserveLoad is called after every load of the file with the time
+	// the load function took. On success version is the content hash of
+	// the loaded file and err is nil.
+	ObserveLoad(file fs.File, duration time.Duration, version Version, err error)
+
+	// ObserveLockWait is called with the time a write by method,
+	// like "Set" or "Mutate", waited for the in-process and OS-level
+	// write lock of the file.
+	ObserveLockWait(file fs.File, method string, wait time.Duration)
+
+	// ObserveWrite is called after every write of the file by method with
+	// the time it took including the lock wait. On success version is the
+	// content hash of the written file and err is nil.
+	ObserveWrite(file fs.File, method string, duration time.Duration, version Version, err error)
+}
+
+// WithMetrics makes the Loader report load and write counts, durations,
+// lock waits and content versions to metrics.
+//
+// The content version of a load is taken from the content the load function
+// reported with ReportContent, like the load functions of this package do.
+// For other load functions and after every write the file is read again
+// while the Loader still holds its locks, so only pass WithMetrics for
+// files where that is cheap.
+//
+// Example exposing Prometheus metrics:
+//
+//	collector := metrics.NewCollector()
+//	loader := dynconfig.MustLoadAndWatch(
+//	    "config.json",
+//	    dynconfig.LoadJSON[Config],
+//	    nil, nil, nil, nil,
+//	    dynconfig.WithMetrics(collector),
+//	)
+//	http.Handle("/metrics", collector)
+func WithMetrics(metrics Metrics) Option {
+	return func(o *loaderOptions) {
+		o.metrics = metrics
+	}
+}
+
+// metricsVersion returns the content version of the file for the Metrics
+// if configured, taken from the content reported by the load with report
+// or read from the file, or an empty Version if it can't be read.
+// The caller must hold l.mtx, and after a write the write lock.
+func (l *Loader[T]) metricsVersion(ctx context.Context, report *loadReport) Version {
+	if l.opts.metrics == nil || l.file == "" {
+		return ""
+	}
+	if version := report.version(); version != "" {
+		return version
+	}
+	version, _ := l.fileVersion(ctx)
+	return version
+}
+
+// observeLoad logs and reports to the Metrics the result of a load that
+// started at start and read the file content with version.
+func (l *Loader[T]) observeLoad(ctx context.Context, start time.Time, version Version, err error) {
+	duration := time.Since(start)
+	l.logLoad(ctx, duration, err)
+	if l.opts.metrics != nil {
+		l.opts.metrics.ObserveLoad(l.file, duration, version, err)
+	}
+}
+
+// observeWrite logs and reports to the Metrics the result of the write
+// method that started at start and wrote the file content with version.
+func (l *Loader[T]) observeWrite(ctx context.Context, method string, start time.Time, version Version, err error) {
+	duration := time.Since(start)
+	l.logWrite(ctx, method, duration, err)
+	if l.opts.metrics != nil {
+		l.opts.metrics.ObserveWrite(l.file, method, duration, version, err)
+	}
+}
+
+// observeLockWait logs and reports to the Metrics the time the write
+// method waited for the write lock since start.
+func (l *Loader[T]) observeLockWait(ctx context.Context, method string, start time.Time) {
+	wait := time.Since(start)
+	l.logLockWait(ctx, method, wait)
+	if l.opts.metrics != nil {
+		l.opts.metrics.ObserveLockWait(l.file, method, wait)
+	}
+}
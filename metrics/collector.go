@@ -0,0 +1,232 @@
+// Package metrics implements dynconfig.Metrics with a Collector that
+// exports the measurements of Loaders configured with dynconfig.WithMetrics
+// in the Prometheus text exposition format and as an expvar variable,
+// without depending on a Prometheus client library.
+//
+// Example:
+//
+//	collector := metrics.NewCollector()
+//	collector.Publish("dynconfig") // Exposed by expvar at /debug/vars
+//
+//	loader := dynconfig.MustLoadAndWatch(
+//	    "config.json",
+//	    dynconfig.LoadJSON[Config],
+//	    nil, nil, nil, nil,
+//	    dynconfig.WithMetrics(collector),
+//	)
+//
+//	http.Handle("/metrics", collector) // Prometheus text format
+package metrics
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ungerik/go-dynconfig"
+	"github.com/ungerik/go-fs"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the histogram buckets
+// for load durations and lock waits used by NewCollector.
+var DefaultBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var _ dynconfig.Metrics = (*Collector)(nil)
+
+// Collector implements dynconfig.Metrics by collecting the measurements
+// of any number of Loaders per configuration file.
+// It is safe for concurrent use.
+type Collector struct {
+	buckets []float64
+
+	mtx   sync.Mutex
+	files map[fs.File]*FileMetrics
+}
+
+// NewCollector returns a Collector using DefaultBuckets for its histograms.
+func NewCollector() *Collector {
+	return NewCollectorWithBuckets(DefaultBuckets)
+}
+
+// NewCollectorWithBuckets returns a Collector using buckets as upper bounds
+// in seconds of the histogram buckets for load durations and lock waits.
+func NewCollectorWithBuckets(buckets []float64) *Collector {
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return &Collector{
+		buckets: slices.Compact(buckets),
+		files:   make(map[fs.File]*FileMetrics),
+	}
+}
+
+// FileMetrics are the metrics collected for one configuration file.
+type FileMetrics struct {
+	File fs.File `json:"file"`
+	// Loads is the number of successful loads.
+	Loads uint64 `json:"loads"`
+	// LoadFailures is the number of failed loads.
+	LoadFailures uint64 `json:"loadFailures"`
+	// LoadDuration is the histogram of the durations of all loads.
+	LoadDuration Histogram `json:"loadDuration"`
+	// Writes is the number of successful writes by Set, Mutate and friends.
+	Writes uint64 `json:"writes"`
+	// WriteFailures is the number of failed writes.
+	WriteFailures uint64 `json:"writeFailures"`
+	// LockWait is the histogram of the time writes waited for the lock.
+	LockWait Histogram `json:"lockWait"`
+	// Methods are the write metrics per write method, like "Set" or "Mutate".
+	Methods map[string]*WriteMetrics `json:"methods,omitempty"`
+	// LastSuccess is the time of the last successful load or write.
+	LastSuccess time.Time `json:"lastSuccess"`
+	// Version is the content hash of the file after the last successful
+	// load or write.
+	Version dynconfig.Version `json:"version"`
+}
+
+// WriteMetrics are the metrics collected for the writes
+// of a configuration file by one method.
+type WriteMetrics struct {
+	// Writes is the number of successful writes.
+	Writes uint64 `json:"writes"`
+	// WriteFailures is the number of failed writes.
+	WriteFailures uint64 `json:"writeFailures"`
+	// LockWait is the histogram of the time writes waited for the lock.
+	LockWait Histogram `json:"lockWait"`
+}
+
+// Histogram counts observed durations in seconds in cumulative buckets
+// like a Prometheus histogram.
+type Histogram struct {
+	// Buckets are the upper bounds of the buckets in seconds.
+	Buckets []float64 `json:"buckets"`
+	// Counts are the cumulative counts of observations less than or equal
+	// to the upper bound of the bucket with the same index.
+	Counts []uint64 `json:"counts"`
+	// Count is the number of all observations.
+	Count uint64 `json:"count"`
+	// Sum is the sum of all observations in seconds.
+	Sum float64 `json:"sum"`
+}
+
+// observe adds the duration d to the histogram.
+func (h *Histogram) observe(d time.Duration) {
+	seconds := d.Seconds()
+	for i, bound := range h.Buckets {
+		if seconds <= bound {
+			h.Counts[i]++
+		}
+	}
+	h.Count++
+	h.Sum += seconds
+}
+
+// clone returns a deep copy of the histogram.
+func (h Histogram) clone() Histogram {
+	h.Buckets = slices.Clone(h.Buckets)
+	h.Counts = slices.Clone(h.Counts)
+	return h
+}
+
+// file returns the metrics of file, creating them if necessary.
+// The caller must hold c.mtx.
+func (c *Collector) file(file fs.File) *FileMetrics {
+	m := c.files[file]
+	if m == nil {
+		m = &FileMetrics{
+			File:         file,
+			LoadDuration: Histogram{Buckets: c.buckets, Counts: make([]uint64, len(c.buckets))},
+			LockWait:     Histogram{Buckets: c.buckets, Counts: make([]uint64, len(c.buckets))},
+		}
+		c.files[file] = m
+	}
+	return m
+}
+
+// method returns the write metrics of method in m, creating them if necessary.
+// The caller must hold c.mtx.
+func (c *Collector) method(m *FileMetrics, method string) *WriteMetrics {
+	w := m.Methods[method]
+	if w == nil {
+		if m.Methods == nil {
+			m.Methods = make(map[string]*WriteMetrics)
+		}
+		w = &WriteMetrics{
+			LockWait: Histogram{Buckets: c.buckets, Counts: make([]uint64, len(c.buckets))},
+		}
+		m.Methods[method] = w
+	}
+	return w
+}
+
+// ObserveLoad implements dynconfig.Metrics.
+func (c *Collector) ObserveLoad(file fs.File, duration time.Duration, version dynconfig.Version, err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	m := c.file(file)
+	m.LoadDuration.observe(duration)
+	if err != nil {
+		m.LoadFailures++
+		return
+	}
+	m.Loads++
+	m.LastSuccess = time.Now()
+	m.Version = version
+}
+
+// ObserveLockWait implements dynconfig.Metrics.
+func (c *Collector) ObserveLockWait(file fs.File, method string, wait time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	m := c.file(file)
+	m.LockWait.observe(wait)
+	c.method(m, method).LockWait.observe(wait)
+}
+
+// ObserveWrite implements dynconfig.Metrics.
+func (c *Collector) ObserveWrite(file fs.File, method string, duration time.Duration, version dynconfig.Version, err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	m := c.file(file)
+	w := c.method(m, method)
+	if err != nil {
+		m.WriteFailures++
+		w.WriteFailures++
+		return
+	}
+	m.Writes++
+	w.Writes++
+	m.LastSuccess = time.Now()
+	m.Version = version
+}
+
+// Snapshot returns a copy of the metrics of all observed files
+// sorted by file.
+func (c *Collector) Snapshot() []FileMetrics {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	files := slices.Sorted(maps.Keys(c.files))
+	snapshot := make([]FileMetrics, len(files))
+	for i, file := range files {
+		m := *c.files[file]
+		m.LoadDuration = m.LoadDuration.clone()
+		m.LockWait = m.LockWait.clone()
+		if m.Methods != nil {
+			methods := make(map[string]*WriteMetrics, len(m.Methods))
+			for method, w := range m.Methods {
+				methods[method] = &WriteMetrics{
+					Writes:        w.Writes,
+					WriteFailures: w.WriteFailures,
+					LockWait:      w.LockWait.clone(),
+				}
+			}
+			m.Methods = methods
+		}
+		snapshot[i] = m
+	}
+	return snapshot
+}
@@ -0,0 +1,15 @@
+package metrics
+
+import "expvar"
+
+// Var returns an expvar.Var whose value is the JSON of Snapshot.
+func (c *Collector) Var() expvar.Var {
+	return expvar.Func(func() any { return c.Snapshot() })
+}
+
+// Publish publishes the metrics as expvar variable with name,
+// shown by the expvar handler at /debug/vars.
+// Like expvar.Publish it panics if name is already used.
+func (c *Collector) Publish(name string) {
+	expvar.Publish(name, c.Var())
+}
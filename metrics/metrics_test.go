@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ungerik/go-dynconfig"
+	"github.com/ungerik/go-fs"
+)
+
+type counter struct {
+	Value int `json:"value"`
+}
+
+func newTestLoader(t *testing.T, collector *Collector) (*dynconfig.Loader[counter], fs.File) {
+	t.Helper()
+	file := fs.File(filepath.Join(t.TempDir(), "counter.json"))
+	err := file.WriteAllString(`{"value": 1}`)
+	if err != nil {
+		t.Fatalf("write file: %s", err)
+	}
+	loader := dynconfig.NewLoader(
+		file,
+		dynconfig.LoadJSON[counter],
+		dynconfig.SaveJSON[counter](),
+		nil, nil, nil,
+		dynconfig.WithMetrics(collector),
+	)
+	t.Cleanup(func() { loader.Close() })
+
+	// One successful load, one write, one failed load
+	if _, err = loader.Load(); err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	if err = loader.Set(counter{Value: 2}); err != nil {
+		t.Fatalf("Set: %s", err)
+	}
+	if err = file.WriteAllString(`not json`); err != nil {
+		t.Fatalf("write file: %s", err)
+	}
+	loader.Invalidate()
+	if _, err = loader.Load(); err == nil {
+		t.Fatal("Load of invalid JSON succeeded")
+	}
+	return loader, file
+}
+
+func httpGet(t *testing.T, handler http.Handler) (contentType, body string) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET: %s", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %s", err)
+	}
+	return resp.Header.Get("Content-Type"), string(data)
+}
+
+func TestCollector_Snapshot(t *testing.T) {
+	collector := NewCollector()
+	_, file := newTestLoader(t, collector)
+
+	snapshot := collector.Snapshot()
+	if len(snapshot) != 1 {
+		t.Fatalf("got %d files, want 1", len(snapshot))
+	}
+	m := snapshot[0]
+	if m.File != file || m.Loads != 1 || m.LoadFailures != 1 || m.Writes != 1 || m.WriteFailures != 0 {
+		t.Errorf("metrics = %+v", m)
+	}
+	if m.LoadDuration.Count != 2 || m.LockWait.Count != 1 {
+		t.Errorf("histogram counts = %d loads, %d lock waits, want 2 and 1", m.LoadDuration.Count, m.LockWait.Count)
+	}
+	if set := m.Methods["Set"]; len(m.Methods) != 1 || set == nil || set.Writes != 1 || set.WriteFailures != 0 || set.LockWait.Count != 1 {
+		t.Errorf("Methods = %+v, want one write by Set", m.Methods)
+	}
+	if m.LastSuccess.IsZero() || time.Since(m.LastSuccess) > time.Minute {
+		t.Errorf("LastSuccess = %s, want now", m.LastSuccess)
+	}
+	// The failed load keeps the version written by Set
+	if m.Version == "" {
+		t.Error("Version is empty")
+	}
+}
+
+func TestCollector_Prometheus(t *testing.T) {
+	collector := NewCollectorWithBuckets([]float64{60, 0.5, 60})
+	_, file := newTestLoader(t, collector)
+
+	contentType, body := httpGet(t, collector)
+	if contentType != PrometheusContentType {
+		t.Errorf("Content-Type = %q, want %q", contentType, PrometheusContentType)
+	}
+	label := `file="` + string(file) + `"`
+	version := collector.Snapshot()[0].Version
+	for _, line := range []string{
+		"# TYPE dynconfig_loads_total counter",
+		"dynconfig_loads_total{" + label + "} 1",
+		"dynconfig_load_failures_total{" + label + "} 1",
+		"# TYPE dynconfig_load_duration_seconds histogram",
+		"dynconfig_load_duration_seconds_bucket{" + label + `,le="0.5"} 2`,
+		"dynconfig_load_duration_seconds_bucket{" + label + `,le="60"} 2`,
+		"dynconfig_load_duration_seconds_bucket{" + label + `,le="+Inf"} 2`,
+		"dynconfig_load_duration_seconds_count{" + label + "} 2",
+		"dynconfig_writes_total{" + label + `,method="Set"} 1`,
+		"dynconfig_write_failures_total{" + label + `,method="Set"} 0`,
+		"dynconfig_lock_wait_seconds_count{" + label + `,method="Set"} 1`,
+		"dynconfig_config_info{" + label + `,version="` + string(version) + `"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing line %q in:\n%s", line, body)
+		}
+	}
+	if !strings.Contains(body, "dynconfig_last_success_timestamp_seconds{"+label+"} ") {
+		t.Errorf("missing last success timestamp in:\n%s", body)
+	}
+}
+
+func TestCollector_Expvar(t *testing.T) {
+	collector := NewCollector()
+	collector.Publish("dynconfig_test")
+	_, file := newTestLoader(t, collector)
+
+	_, body := httpGet(t, expvar.Handler())
+	var vars struct {
+		Dynconfig []FileMetrics `json:"dynconfig_test"`
+	}
+	err := json.Unmarshal([]byte(body), &vars)
+	if err != nil {
+		t.Fatalf("invalid expvar JSON: %s", err)
+	}
+	if len(vars.Dynconfig) != 1 || vars.Dynconfig[0].File != file || vars.Dynconfig[0].Loads != 1 {
+		t.Errorf("expvar metrics = %+v", vars.Dynconfig)
+	}
+}
+
+func TestLabelValue(t *testing.T) {
+	if got, want := labelValue("a\"b\\c\nd"), `"a\"b\\c\nd"`; got != want {
+		t.Errorf("labelValue = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PrometheusContentType is the content type of the Prometheus text
+// exposition format written by WritePrometheus.
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// WritePrometheus writes the metrics in the Prometheus text exposition
+// format with a file label for every configuration file, and a method
+// label like "Set" or "Mutate" for the write metrics:
+//
+//   - dynconfig_loads_total: counter of successful loads
+//   - dynconfig_load_failures_total: counter of failed loads
+//   - dynconfig_load_duration_seconds: histogram of load durations
+//   - dynconfig_writes_total: counter of successful writes per method
+//   - dynconfig_write_failures_total: counter of failed writes per method
+//   - dynconfig_lock_wait_seconds: histogram of write lock waits per method
+//   - dynconfig_last_success_timestamp_seconds: Unix time of the last
+//     successful load or write
+//   - dynconfig_config_info: always 1, with the content hash of the file
+//     as version label
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	snapshot := c.Snapshot()
+	b := bufio.NewWriter(w)
+
+	// series calls write with the labels of every file,
+	// or of every write method of every file if perMethod is true
+	series := func(perMethod bool, write func(labels string, m *FileMetrics, w *WriteMetrics)) {
+		for i := range snapshot {
+			m := &snapshot[i]
+			labels := "file=" + labelValue(string(m.File))
+			if !perMethod {
+				write(labels, m, nil)
+				continue
+			}
+			for _, method := range slices.Sorted(maps.Keys(m.Methods)) {
+				write(labels+",method="+labelValue(method), m, m.Methods[method])
+			}
+		}
+	}
+	counter := func(name, help string, perMethod bool, value func(*FileMetrics, *WriteMetrics) uint64) {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		series(perMethod, func(labels string, m *FileMetrics, w *WriteMetrics) {
+			fmt.Fprintf(b, "%s{%s} %d\n", name, labels, value(m, w))
+		})
+	}
+	histogram := func(name, help string, perMethod bool, value func(*FileMetrics, *WriteMetrics) *Histogram) {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+		series(perMethod, func(labels string, m *FileMetrics, w *WriteMetrics) {
+			h := value(m, w)
+			for j, bound := range h.Buckets {
+				fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatFloat(bound), h.Counts[j])
+			}
+			fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.Count)
+			fmt.Fprintf(b, "%s_sum{%s} %s\n", name, labels, formatFloat(h.Sum))
+			fmt.Fprintf(b, "%s_count{%s} %d\n", name, labels, h.Count)
+		})
+	}
+
+	counter("dynconfig_loads_total", "Number of successful configuration loads.", false,
+		func(m *FileMetrics, _ *WriteMetrics) uint64 { return m.Loads })
+	counter("dynconfig_load_failures_total", "Number of failed configuration loads.", false,
+		func(m *FileMetrics, _ *WriteMetrics) uint64 { return m.LoadFailures })
+	histogram("dynconfig_load_duration_seconds", "Duration of configuration loads in seconds.", false,
+		func(m *FileMetrics, _ *WriteMetrics) *Histogram { return &m.LoadDuration })
+	counter("dynconfig_writes_total", "Number of successful configuration writes.", true,
+		func(_ *FileMetrics, w *WriteMetrics) uint64 { return w.Writes })
+	counter("dynconfig_write_failures_total", "Number of failed configuration writes.", true,
+		func(_ *FileMetrics, w *WriteMetrics) uint64 { return w.WriteFailures })
+	histogram("dynconfig_lock_wait_seconds", "Time configuration writes waited for the write lock in seconds.", true,
+		func(_ *FileMetrics, w *WriteMetrics) *Histogram { return &w.LockWait })
+
+	const lastSuccess = "dynconfig_last_success_timestamp_seconds"
+	fmt.Fprintf(b, "# HELP %s Unix time of the last successful configuration load or write.\n# TYPE %s gauge\n", lastSuccess, lastSuccess)
+	for _, m := range snapshot {
+		if !m.LastSuccess.IsZero() {
+			fmt.Fprintf(b, "%s{file=%s} %s\n", lastSuccess, labelValue(string(m.File)), formatFloat(float64(m.LastSuccess.UnixNano())/1e9))
+		}
+	}
+	const info = "dynconfig_config_info"
+	fmt.Fprintf(b, "# HELP %s Content hash of the configuration file as version label.\n# TYPE %s gauge\n", info, info)
+	for _, m := range snapshot {
+		if m.Version != "" {
+			fmt.Fprintf(b, "%s{file=%s,version=%s} 1\n", info, labelValue(string(m.File)), labelValue(string(m.Version)))
+		}
+	}
+	return b.Flush()
+}
+
+// ServeHTTP implements http.Handler by writing the metrics
+// in the Prometheus text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", PrometheusContentType)
+	_ = c.WritePrometheus(w)
+}
+
+// labelValue returns str quoted and escaped as Prometheus label value.
+func labelValue(str string) string {
+	str = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(str)
+	return `"` + str + `"`
+}
+
+// formatFloat formats f as Prometheus sample or label value.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,114 @@
+package dynconfig
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ungerik/go-fs"
+)
+
+// recordingMetrics implements Metrics by recording every call.
+type recordingMetrics struct {
+	mtx    sync.Mutex
+	events []string
+	loaded Version
+	wrote  Version
+}
+
+func (m *recordingMetrics) ObserveLoad(file fs.File, duration time.Duration, version Version, err error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if err != nil {
+		m.events = append(m.events, "load error")
+		return
+	}
+	m.events = append(m.events, "load")
+	m.loaded = version
+}
+
+func (m *recordingMetrics) ObserveLockWait(file fs.File, method string, wait time.Duration) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.events = append(m.events, "lock "+method)
+}
+
+func (m *recordingMetrics) ObserveWrite(file fs.File, method string, duration time.Duration, version Version, err error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if err != nil {
+		m.events = append(m.events, "write error "+method)
+		return
+	}
+	m.events = append(m.events, "write "+method)
+	m.wrote = version
+}
+
+func TestWithMetrics(t *testing.T) {
+	metrics := new(recordingMetrics)
+	file := writeTempJSON(t, "counter.json", `{"value": 1}`)
+	loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil, WithMetrics(metrics))
+
+	_, loadedVersion, err := loader.LoadVersioned()
+	if err != nil {
+		t.Fatalf("LoadVersioned: %s", err)
+	}
+	err = loader.Mutate(true, func(c counter) (counter, error) { c.Value++; return c, nil })
+	if err != nil {
+		t.Fatalf("Mutate: %s", err)
+	}
+	_, err = loader.SetIfVersion("", counter{Value: 5})
+	if err == nil {
+		t.Fatal("SetIfVersion of existing file with empty version succeeded")
+	}
+
+	want := []string{
+		"load",
+		"lock Mutate", "load", "write Mutate",
+		"lock SetIfVersion", "write error SetIfVersion",
+	}
+	if !slices.Equal(metrics.events, want) {
+		t.Errorf("events = %v, want %v", metrics.events, want)
+	}
+	_, currentVersion, err := loader.LoadVersioned()
+	if err != nil {
+		t.Fatalf("LoadVersioned: %s", err)
+	}
+	if metrics.wrote != currentVersion || metrics.wrote == loadedVersion {
+		t.Errorf("written version = %q, want current %q", metrics.wrote, currentVersion)
+	}
+}
+
+// TestWithMetrics_ReportedContent verifies that the load version is taken
+// from the content reported by the load function and the write version
+// from the written file.
+func TestWithMetrics_ReportedContent(t *testing.T) {
+	metrics := new(recordingMetrics)
+	file := writeTempJSON(t, "counter.json", `{"value": 1}`)
+	load := func(f fs.File) (counter, error) {
+		ReportContent(f, []byte("reported"))
+		return counter{Value: 1}, nil
+	}
+	loader := NewLoader(file, load, SaveJSON[counter](), nil, nil, nil, WithMetrics(metrics))
+
+	_, err := loader.Load()
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	if want := contentVersion([]byte("reported")); metrics.loaded != want {
+		t.Errorf("loaded version = %q, want %q of the reported content", metrics.loaded, want)
+	}
+
+	err = loader.Set(counter{Value: 2})
+	if err != nil {
+		t.Fatalf("Set: %s", err)
+	}
+	data, err := file.ReadAll()
+	if err != nil {
+		t.Fatalf("read file: %s", err)
+	}
+	if want := contentVersion(data); metrics.wrote != want {
+		t.Errorf("written version = %q, want %q", metrics.wrote, want)
+	}
+}
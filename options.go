@@ -0,0 +1,40 @@
+package dynconfig
+
+import "log/slog"
+
+// Option configures optional behavior of a Loader.
+// Options are passed as trailing arguments to NewLoader, LoadAndWatch
+// and MustLoadAndWatch.
+//
+// Example:
+//
+//	loader := dynconfig.MustLoadAndWatch(
+//	    "config.json",
+//	    dynconfig.LoadJSON[Config],
+//	    dynconfig.SaveJSON[Config]("  "),
+//	    nil, nil, nil,
+//	    dynconfig.WithBackups(5),
+//	)
+type Option func(*loaderOptions)
+
+// loaderOptions holds the values set by the Options of a Loader.
+type loaderOptions struct {
+	backups          int
+	backupTimestamps bool
+	nonDurableWrites bool
+	lockStrategy     LockStrategy
+	fileOps          *fileOps // nil for osFileOps
+	logger           *slog.Logger
+	metrics          Metrics
+}
+
+// newLoaderOptions returns the loaderOptions with opts applied.
+func newLoaderOptions(opts []Option) loaderOptions {
+	var o loaderOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
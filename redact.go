@@ -0,0 +1,317 @@
+package dynconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// RedactedString replaces the non-empty string values of secret fields
+// in the results of Redacted and Dump.
+const RedactedString = "[REDACTED]"
+
+// isSecret reports whether the struct field is tagged
+// with secret:"true" or dynconfig:"secret".
+func isSecret(field reflect.StructField) bool {
+	return field.Tag.Get("secret") == "true" ||
+		slices.Contains(strings.Split(field.Tag.Get("dynconfig"), ","), "secret")
+}
+
+// Redacted returns a copy of config with the values of all exported struct
+// fields tagged with secret:"true" or dynconfig:"secret" masked, found in
+// nested structs, pointers, slices, arrays, maps and interface values.
+// Non-empty strings and pointers to them are replaced with RedactedString,
+// other non-zero values with their zero value; empty values stay empty
+// so an unset secret can be told apart.
+//
+// Only the parts of config that contain secret fields are copied,
+// the rest is shared with config, which is never modified.
+// Unexported fields are copied as is, except for the exported fields
+// of embedded structs, which encoding/json marshals like direct fields.
+//
+// Example:
+//
+//	type Config struct {
+//	    Host     string
+//	    Password string `secret:"true"`
+//	    APIKey   string `dynconfig:"secret"`
+//	}
+//
+//	safe := dynconfig.Redacted(config.Get())
+//	// safe.Password == "[REDACTED]"
+func Redacted[T any](config T) T {
+	r := redactor{copies: make(map[pointerKey]reflect.Value)}
+	v := reflect.ValueOf(&config).Elem()
+	v.Set(r.value(v))
+	return config
+}
+
+// Dump returns config as indented JSON with the values of secret fields
+// masked like Redacted, for logging and debug output.
+// If config can't be marshalled as JSON, it is formatted with %+v.
+//
+// Example:
+//
+//	log.Printf("Loaded config: %s", dynconfig.Dump(config.Get()))
+func Dump(config any) string {
+	config = Redacted(config)
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%+v", config)
+	}
+	return string(data)
+}
+
+// RestoreRedacted returns edited with the secret fields that still have
+// the redacted value of the corresponding field of current set to the value
+// of current, so a configuration shown with Redacted or Dump can be edited
+// and written back without replacing its secrets with RedactedString.
+//
+// Fields are matched by their path through structs, pointers, slice and
+// array indices, map keys and interface values of the same type.
+// A secret field can't be changed to the redacted value of its current
+// value, like the zero value for non-string types.
+// Maps and values pointed to by edited may be modified.
+func RestoreRedacted[T any](edited, current T) T {
+	restoreRedacted(reflect.ValueOf(&edited).Elem(), reflect.ValueOf(&current).Elem())
+	return edited
+}
+
+// pointerKey identifies a non-nil pointer value.
+type pointerKey struct {
+	typ reflect.Type
+	ptr uintptr
+}
+
+// redactor copies values with secret fields,
+// remembering the copies of pointers to handle cycles.
+type redactor struct {
+	copies map[pointerKey]reflect.Value
+}
+
+func (r *redactor) value(v reflect.Value) reflect.Value {
+	if !mayContainSecrets(v.Type()) {
+		return v
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		key := pointerKey{v.Type(), v.Pointer()}
+		if c, ok := r.copies[key]; ok {
+			return c
+		}
+		c := reflect.New(v.Type().Elem())
+		r.copies[key] = c
+		c.Elem().Set(r.value(v.Elem()))
+		return c
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(r.value(v.Elem()))
+		return c
+
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		r.fields(c)
+		return c
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			c.Index(i).Set(r.value(v.Index(i)))
+		}
+		return c
+
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := range v.Len() {
+			c.Index(i).Set(r.value(v.Index(i)))
+		}
+		return c
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), r.value(iter.Value()))
+		}
+		return c
+	}
+	return v
+}
+
+// fields redacts the fields of the addressable struct copy c in place.
+func (r *redactor) fields(c reflect.Value) {
+	for i := range c.NumField() {
+		field := c.Type().Field(i)
+		switch {
+		case isEmbeddedUnexported(field):
+			// Its exported fields are promoted and settable
+			r.fields(c.Field(i))
+		case !field.IsExported():
+			continue
+		case isSecret(field):
+			c.Field(i).Set(redactedValue(c.Field(i)))
+		default:
+			c.Field(i).Set(r.value(c.Field(i)))
+		}
+	}
+}
+
+// isEmbeddedUnexported reports whether field is an embedded struct
+// of an unexported type, whose exported fields are still marshalled.
+func isEmbeddedUnexported(field reflect.StructField) bool {
+	return field.Anonymous && !field.IsExported() && field.Type.Kind() == reflect.Struct
+}
+
+// redactedValue returns the redacted replacement of the value v
+// of a secret field: RedactedString for non-empty strings and pointers to them,
+// the zero value for other non-zero values.
+func redactedValue(v reflect.Value) reflect.Value {
+	if v.IsZero() {
+		return v
+	}
+	switch {
+	case v.Kind() == reflect.String:
+		return reflect.ValueOf(RedactedString).Convert(v.Type())
+	case v.Kind() == reflect.Pointer && v.Type().Elem().Kind() == reflect.String:
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(reflect.ValueOf(RedactedString).Convert(v.Type().Elem()))
+		return c
+	}
+	return reflect.Zero(v.Type())
+}
+
+// restoreRedacted sets the secret fields of dst that still have the
+// redacted value of the corresponding field in current back to the
+// value in current. dst must be settable.
+func restoreRedacted(dst, current reflect.Value) {
+	restorer{visited: make(map[pointerKey]bool)}.restore(dst, current)
+}
+
+// restorer implements restoreRedacted,
+// remembering the visited pointers to handle cycles.
+type restorer struct {
+	visited map[pointerKey]bool
+}
+
+func (r restorer) restore(dst, current reflect.Value) {
+	if dst.Type() != current.Type() || !mayContainSecrets(dst.Type()) {
+		return
+	}
+	switch dst.Kind() {
+	case reflect.Pointer:
+		if dst.IsNil() || current.IsNil() {
+			return
+		}
+		key := pointerKey{dst.Type(), dst.Pointer()}
+		if r.visited[key] {
+			return
+		}
+		r.visited[key] = true
+		r.restore(dst.Elem(), current.Elem())
+
+	case reflect.Interface:
+		if dst.IsNil() || current.IsNil() || dst.Elem().Type() != current.Elem().Type() {
+			return
+		}
+		c := reflect.New(dst.Elem().Type()).Elem()
+		c.Set(dst.Elem())
+		r.restore(c, current.Elem())
+		dst.Set(c)
+
+	case reflect.Struct:
+		for i := range dst.NumField() {
+			field := dst.Type().Field(i)
+			switch {
+			case isEmbeddedUnexported(field):
+				r.restore(dst.Field(i), current.Field(i))
+			case !field.IsExported():
+				continue
+			case isSecret(field):
+				redacted := redactedValue(current.Field(i))
+				if !current.Field(i).IsZero() && reflect.DeepEqual(dst.Field(i).Interface(), redacted.Interface()) {
+					dst.Field(i).Set(current.Field(i))
+				}
+			default:
+				r.restore(dst.Field(i), current.Field(i))
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := range min(dst.Len(), current.Len()) {
+			r.restore(dst.Index(i), current.Index(i))
+		}
+
+	case reflect.Map:
+		if dst.IsNil() || current.IsNil() {
+			return
+		}
+		iter := dst.MapRange()
+		for iter.Next() {
+			cur := current.MapIndex(iter.Key())
+			if !cur.IsValid() {
+				continue
+			}
+			c := reflect.New(iter.Value().Type()).Elem()
+			c.Set(iter.Value())
+			r.restore(c, cur)
+			dst.SetMapIndex(iter.Key(), c)
+		}
+	}
+}
+
+var secretTypes sync.Map // reflect.Type => bool
+
+// mayContainSecrets reports whether values of type t can contain
+// secret fields, always true for interface types.
+func mayContainSecrets(t reflect.Type) bool {
+	if cached, ok := secretTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	result := containsSecrets(t, make(map[reflect.Type]bool))
+	secretTypes.Store(t, result)
+	return result
+}
+
+func containsSecrets(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if visiting[t] {
+		// Recursive type, secrets are found at the first occurrence
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return containsSecrets(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if !field.IsExported() && !isEmbeddedUnexported(field) {
+				continue
+			}
+			if isSecret(field) || containsSecrets(field.Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package dynconfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type redactDatabase struct {
+	Host     string `json:"host"`
+	Password string `json:"password" secret:"true"`
+}
+
+type redactEmbedded struct {
+	Token string `dynconfig:"secret"`
+}
+
+type redactNode struct {
+	Name   string
+	Secret *string `secret:"true"`
+	Next   *redactNode
+}
+
+type redactConfig struct {
+	redactEmbedded
+	Plain    string
+	Password string `secret:"true"`
+	APIKey   string `json:"apiKey" dynconfig:"secret,other"`
+	PIN      int    `secret:"true"`
+	Empty    string `secret:"true"`
+	NotTrue  string `secret:"false"`
+	Nested   []redactDatabase
+	ByName   map[string]redactDatabase
+	Any      any
+	private  string
+}
+
+func TestRedacted(t *testing.T) {
+	config := redactConfig{
+		redactEmbedded: redactEmbedded{Token: "token"},
+		Plain:          "plain",
+		Password:       "password",
+		APIKey:         "key",
+		PIN:            1234,
+		NotTrue:        "visible",
+		Nested:         []redactDatabase{{Host: "a", Password: "pa"}},
+		ByName:         map[string]redactDatabase{"b": {Host: "b", Password: "pb"}},
+		Any:            &redactDatabase{Host: "c", Password: "pc"},
+		private:        "private",
+	}
+
+	got := Redacted(config)
+	want := redactConfig{
+		redactEmbedded: redactEmbedded{Token: RedactedString},
+		Plain:          "plain",
+		Password:       RedactedString,
+		APIKey:         RedactedString,
+		NotTrue:        "visible",
+		Nested:         []redactDatabase{{Host: "a", Password: RedactedString}},
+		ByName:         map[string]redactDatabase{"b": {Host: "b", Password: RedactedString}},
+		Any:            &redactDatabase{Host: "c", Password: RedactedString},
+		private:        "private",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Redacted =\n%+v\nwant\n%+v", got, want)
+	}
+	if config.Password != "password" || config.Nested[0].Password != "pa" || config.ByName["b"].Password != "pb" || config.Any.(*redactDatabase).Password != "pc" {
+		t.Errorf("Redacted modified the original value: %+v", config)
+	}
+
+	// As any and as pointer
+	if got := Redacted[any](config).(redactConfig); !reflect.DeepEqual(got, want) {
+		t.Errorf("Redacted[any] =\n%+v\nwant\n%+v", got, want)
+	}
+	if got := Redacted(&config); got == &config || !reflect.DeepEqual(*got, want) {
+		t.Errorf("Redacted(&config) =\n%+v\nwant a copy of\n%+v", got, want)
+	}
+
+	// Cyclic pointers
+	secret := "s3cret"
+	n := &redactNode{Name: "a", Secret: &secret}
+	n.Next = n
+	r := Redacted(n)
+	if r == n || r.Next != r || *r.Secret != RedactedString || secret != "s3cret" {
+		t.Errorf("Redacted of cyclic node = %+v", r)
+	}
+
+	// Values without secrets are returned unchanged
+	if got := Redacted("plain"); got != "plain" {
+		t.Errorf("Redacted string = %v", got)
+	}
+	if got := Redacted[any](nil); got != nil {
+		t.Errorf("Redacted[any](nil) = %v", got)
+	}
+}
+
+func TestRestoreRedacted(t *testing.T) {
+	current := redactConfig{
+		redactEmbedded: redactEmbedded{Token: "token"},
+		Password:       "password",
+		APIKey:         "key",
+		PIN:            1234,
+		Nested:         []redactDatabase{{Host: "a", Password: "pa"}},
+		ByName:         map[string]redactDatabase{"b": {Host: "b", Password: "pb"}},
+	}
+	edited := Redacted(current)
+	edited.Plain = "edited"
+	edited.APIKey = "new key"
+	edited.ByName["b"] = redactDatabase{Host: "b", Password: "new"}
+
+	got := RestoreRedacted(edited, current)
+
+	want := current
+	want.Plain = "edited"
+	want.APIKey = "new key"
+	want.ByName = map[string]redactDatabase{"b": {Host: "b", Password: "new"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RestoreRedacted =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// As any, like the admin package uses it
+	restored := RestoreRedacted[any](Redacted(current), current).(redactConfig)
+	if !reflect.DeepEqual(restored, current) {
+		t.Errorf("RestoreRedacted[any] =\n%+v\nwant\n%+v", restored, current)
+	}
+}
+
+func TestDump(t *testing.T) {
+	config := &redactDatabase{Host: "db", Password: "hunter2"}
+	dump := Dump(config)
+	if strings.Contains(dump, "hunter2") {
+		t.Errorf("Dump leaks the secret:\n%s", dump)
+	}
+	var got redactDatabase
+	err := json.Unmarshal([]byte(dump), &got)
+	if err != nil {
+		t.Fatalf("Dump is not JSON: %s\n%s", err, dump)
+	}
+	if want := (redactDatabase{Host: "db", Password: RedactedString}); got != want {
+		t.Errorf("Dump = %+v, want %+v", got, want)
+	}
+
+	// Not marshallable as JSON
+	type withFunc struct {
+		Func     func()
+		Password string `secret:"true"`
+	}
+	dump = Dump(withFunc{Password: "hunter2"})
+	if strings.Contains(dump, "hunter2") || !strings.Contains(dump, RedactedString) {
+		t.Errorf("Dump with %%+v fallback = %s", dump)
+	}
+}
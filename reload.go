@@ -0,0 +1,236 @@
+package dynconfig
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+
+	"github.com/ungerik/go-fs"
+)
+
+// Reload invalidates the configuration and immediately loads it again,
+// instead of waiting for the next Get or Load call.
+//
+// Use it when something the load function depends on changed without the
+// file changing, like environment variables overlaid by the loadenv
+// package. Load errors are handled like in Load: the onError callback is
+// called and the error is returned.
+//
+// Safe to call on a nil Loader (returns an error). Thread-safe.
+//
+// Example:
+//
+//	os.Setenv("APP_PORT", "9090")
+//	if err := loader.Reload(); err != nil {
+//	    log.Printf("Config reload error: %v", err)
+//	}
+func (l *Loader[T]) Reload() error {
+	if l == nil {
+		return errors.New("<nil> Loader")
+	}
+	l.Invalidate()
+	_, err := l.Load()
+	return err
+}
+
+// ReloadOn calls Reload every time the process receives one of the signals,
+// SIGHUP if none are passed, until the returned stop function or Close
+// is called.
+//
+// Reload errors are handled by the onError callback, if any, and otherwise
+// ignored; the last known configuration stays in use.
+// Use Register and HandleSIGHUP instead to reload several loaders
+// and get notified about the result of every reload.
+//
+// Safe to call on a nil Loader (the returned stop function does nothing).
+//
+// Example:
+//
+//	loader := dynconfig.MustLoadAndWatch("config.json", loadenv.LoadEnvJSON[*Config], nil, nil, nil, nil)
+//	stop := loader.ReloadOn(syscall.SIGHUP, syscall.SIGUSR1)
+//	defer stop()
+func (l *Loader[T]) ReloadOn(signals ...os.Signal) (stop func()) {
+	if l == nil {
+		return func() {}
+	}
+	stop = onSignals(signals, func() { _ = l.Reload() })
+	l.addCloseFunc(stop)
+	return stop
+}
+
+// Reloader is implemented by Loader and registered with Register
+// to be reloaded by ReloadAll.
+type Reloader interface {
+	// File returns the configuration file, used to identify
+	// the Reloader in a ReloadResult.
+	File() fs.File
+	// Reload invalidates and immediately reloads the configuration.
+	Reload() error
+}
+
+// ReloadResult is the result of reloading a single registered Reloader.
+type ReloadResult struct {
+	Reloader Reloader
+	File     fs.File
+	// Err is nil if the reload succeeded.
+	Err error
+}
+
+var (
+	registryMtx sync.Mutex
+	registry    []*registration
+)
+
+// registration wraps a registered Reloader so the same Reloader can be
+// registered and unregistered more than once.
+type registration struct {
+	reloader Reloader
+}
+
+// Register adds r to the package registry of reloaders reloaded by ReloadAll,
+// HandleSignals and HandleSIGHUP, and returns a function to remove it again.
+// A registered Loader is also removed by its Close method.
+//
+// Example:
+//
+//	loader := dynconfig.MustLoadAndWatch("config.json", loadenv.LoadEnvJSON[*Config], nil, nil, nil, nil)
+//	unregister := dynconfig.Register(loader)
+//	defer unregister()
+func Register(r Reloader) (unregister func()) {
+	reg := &registration{reloader: r}
+
+	registryMtx.Lock()
+	registry = append(registry, reg)
+	registryMtx.Unlock()
+
+	var once sync.Once
+	unregister = func() {
+		once.Do(func() {
+			registryMtx.Lock()
+			defer registryMtx.Unlock()
+
+			registry = slices.DeleteFunc(registry, func(r *registration) bool { return r == reg })
+		})
+	}
+	if c, ok := r.(closeNotifier); ok {
+		// A closed Loader removes itself from the registry
+		c.addCloseFunc(unregister)
+	}
+	return unregister
+}
+
+// Registered returns the currently registered reloaders
+// in the order they were registered.
+//
+// Registered Loaders implement AnyLoader to inspect them
+// without knowing their configuration type.
+func Registered() []Reloader {
+	registryMtx.Lock()
+	defer registryMtx.Unlock()
+
+	reloaders := make([]Reloader, len(registry))
+	for i, reg := range registry {
+		reloaders[i] = reg.reloader
+	}
+	return reloaders
+}
+
+// ReloadAll reloads every registered Reloader in the order they were
+// registered and returns the result for each of them.
+//
+// A failing Reloader does not stop the others from being reloaded.
+//
+// Example:
+//
+//	for _, result := range dynconfig.ReloadAll() {
+//	    if result.Err != nil {
+//	        log.Printf("Reloading %s failed: %v", result.File, result.Err)
+//	    }
+//	}
+func ReloadAll() []ReloadResult {
+	reloaders := Registered()
+	results := make([]ReloadResult, len(reloaders))
+	for i, r := range reloaders {
+		results[i] = ReloadResult{
+			Reloader: r,
+			File:     r.File(),
+			Err:      r.Reload(),
+		}
+	}
+	return results
+}
+
+// HandleSignals calls ReloadAll every time the process receives one of the
+// signals, SIGHUP if none are passed, until the returned stop function is
+// called. If report is not nil it is called with the results of every
+// ReloadAll call.
+//
+// Example:
+//
+//	stop := dynconfig.HandleSignals(func(results []dynconfig.ReloadResult) {
+//	    for _, result := range results {
+//	        log.Printf("Reloaded %s: %v", result.File, result.Err)
+//	    }
+//	}, syscall.SIGHUP, syscall.SIGUSR1)
+//	defer stop()
+func HandleSignals(report func([]ReloadResult), signals ...os.Signal) (stop func()) {
+	return onSignals(signals, func() {
+		results := ReloadAll()
+		if report != nil {
+			report(results)
+		}
+	})
+}
+
+// HandleSIGHUP calls ReloadAll every time the process receives SIGHUP,
+// the conventional signal for a daemon to reload its configuration,
+// until the returned stop function is called.
+// If report is not nil it is called with the results of every reload.
+//
+// Example:
+//
+//	dynconfig.Register(dbLoader)
+//	dynconfig.Register(featureLoader)
+//	stop := dynconfig.HandleSIGHUP(func(results []dynconfig.ReloadResult) {
+//	    for _, result := range results {
+//	        if result.Err != nil {
+//	            log.Printf("Reloading %s failed: %v", result.File, result.Err)
+//	        }
+//	    }
+//	})
+//	defer stop()
+func HandleSIGHUP(report func([]ReloadResult)) (stop func()) {
+	return HandleSignals(report, syscall.SIGHUP)
+}
+
+// onSignals calls fn for every received signal, SIGHUP if signals is empty,
+// until the returned stop function is called.
+func onSignals(signals []os.Signal, fn func()) (stop func()) {
+	if len(signals) == 0 {
+		// signal.Notify without signals would relay all incoming signals
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+				fn()
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
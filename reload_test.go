@@ -0,0 +1,130 @@
+package dynconfig
+
+import (
+	"errors"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ungerik/go-fs"
+)
+
+func TestLoader_Reload(t *testing.T) {
+	file := memFile(t, "config.json", `{"value":1}`)
+
+	value := 1
+	load := func(fs.File) (counter, error) { return counter{Value: value}, nil }
+	loader := NewLoader(file, load, nil, nil, nil, nil)
+	if got := loader.Get().Value; got != 1 {
+		t.Fatalf("got %d, want 1", got)
+	}
+
+	value = 2
+	if got := loader.Get().Value; got != 1 {
+		t.Fatalf("got %d, want cached 1", got)
+	}
+	if err := loader.Reload(); err != nil {
+		t.Fatalf("Reload: %s", err)
+	}
+	if !loader.Loaded() {
+		t.Error("Reload must load eagerly")
+	}
+	if got := loader.Get().Value; got != 2 {
+		t.Errorf("got %d, want 2", got)
+	}
+
+	var nilLoader *Loader[counter]
+	if err := nilLoader.Reload(); err == nil {
+		t.Error("expected error for nil Loader")
+	}
+}
+
+func TestReloadAll(t *testing.T) {
+	good := NewLoader(memFile(t, "good.json", `{"value":1}`), LoadJSON[counter], nil, nil, nil, nil)
+	bad := NewLoader(memFile(t, "bad.json", `invalid`), LoadJSON[counter], nil, nil, nil, nil)
+	unregisterGood := Register(good)
+	defer unregisterGood()
+	unregisterBad := Register(bad)
+
+	results := ReloadAll()
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if results[0].File != good.File() || results[0].Err != nil {
+		t.Errorf("results[0] = %+v, want success for %s", results[0], good.File())
+	}
+	if results[1].File != bad.File() || results[1].Err == nil {
+		t.Errorf("results[1] = %+v, want error for %s", results[1], bad.File())
+	}
+
+	unregisterBad()
+	unregisterBad() // Must be idempotent
+	results = ReloadAll()
+	if len(results) != 1 || results[0].Reloader != Reloader(good) {
+		t.Errorf("got %+v, want only the good loader after unregister", results)
+	}
+}
+
+func TestHandleSignals(t *testing.T) {
+	// Set by the test and read by the signal handling goroutine
+	var value atomic.Int64
+	value.Store(1)
+	load := func(fs.File) (counter, error) { return counter{Value: int(value.Load())}, nil }
+	loader := NewLoader(memFile(t, "config.json", ""), load, nil, nil, nil, nil)
+	defer Register(loader)()
+	loader.Get()
+
+	reported := make(chan []ReloadResult, 1)
+	stop := HandleSignals(func(results []ReloadResult) { reported <- results }, syscall.SIGHUP)
+	defer stop()
+
+	value.Store(2)
+	sendSignal(t, syscall.SIGHUP)
+	select {
+	case results := <-reported:
+		if len(results) != 1 || results[0].Err != nil {
+			t.Errorf("got %+v, want one successful result", results)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("signal did not trigger ReloadAll")
+	}
+	if got := loader.Get().Value; got != 2 {
+		t.Errorf("got %d, want 2", got)
+	}
+}
+
+func TestLoader_ReloadOn(t *testing.T) {
+	reloaded := make(chan struct{}, 1)
+	load := func(fs.File) (counter, error) { return counter{}, errors.New("load failed") }
+	onError := func(error) counter {
+		reloaded <- struct{}{}
+		return counter{}
+	}
+	loader := NewLoader(memFile(t, "config.json", ""), load, nil, nil, onError, nil)
+	stop := loader.ReloadOn()
+	defer stop()
+
+	sendSignal(t, syscall.SIGHUP)
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("signal did not trigger Reload")
+	}
+	stop()
+	stop() // Must be idempotent
+}
+
+// sendSignal sends sig to the test process, skipping the test on
+// platforms that don't support it.
+func sendSignal(t *testing.T, sig os.Signal) {
+	t.Helper()
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("FindProcess: %s", err)
+	}
+	if err := p.Signal(sig); err != nil {
+		t.Skipf("sending %s not supported: %s", sig, err)
+	}
+}
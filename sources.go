@@ -0,0 +1,231 @@
+package dynconfig
+
+import (
+	"maps"
+	"sync"
+
+	"github.com/ungerik/go-fs"
+)
+
+// SourceKind tells where a configuration value came from.
+type SourceKind int
+
+const (
+	// SourceFile means the value was read from the configuration file.
+	SourceFile SourceKind = iota
+	// SourceEnv means the value was read from an environment variable.
+	SourceEnv
+	// SourceDefault means the value is a default, for example from an
+	// envDefault struct tag, because the environment variable was not set.
+	SourceDefault
+	// SourceFlag means the value was set by a command line flag
+	// bound with BindFlags.
+	SourceFlag
+)
+
+// String implements fmt.Stringer.
+func (k SourceKind) String() string {
+	switch k {
+	case SourceFile:
+		return "file"
+	case SourceEnv:
+		return "env"
+	case SourceDefault:
+		return "default"
+	case SourceFlag:
+		return "flag"
+	}
+	return "unknown"
+}
+
+// Source describes where a configuration value came from.
+type Source struct {
+	Kind SourceKind
+	// Name is the file path for SourceFile, the environment variable name
+	// for SourceEnv, the name of the unset environment variable (if any)
+	// for SourceDefault, and the flag like "-port" for SourceFlag.
+	Name string
+}
+
+// String returns the kind and name, like "env APP_PORT".
+func (s Source) String() string {
+	if s.Name == "" {
+		return s.Kind.String()
+	}
+	return s.Kind.String() + " " + s.Name
+}
+
+// Sources maps dot separated configuration field paths like "Database.Host"
+// to the source of their value.
+type Sources map[string]Source
+
+// ReportSources is called by load functions to report where the values of
+// the configuration loaded from file came from.
+//
+// When the load function is called by a Loader for file, the reported
+// sources are merged and made available by Loader.Sources after the load
+// succeeds. Reports made outside of a Loader's load call are discarded,
+// so load functions can call ReportSources unconditionally.
+//
+// Example of a load function reporting that a value was overridden:
+//
+//	func loadConfig(file fs.File) (Config, error) {
+//	    cfg, err := dynconfig.LoadJSON[Config](file)
+//	    if err != nil {
+//	        return cfg, err
+//	    }
+//	    if port := os.Getenv("APP_PORT"); port != "" {
+//	        cfg.Port, err = strconv.Atoi(port)
+//	        dynconfig.ReportSources(file, dynconfig.Sources{
+//	            "Port": {Kind: dynconfig.SourceEnv, Name: "APP_PORT"},
+//	        })
+//	    }
+//	    return cfg, err
+//	}
+//
+// Loaders loading the same file take turns, so concurrent Loaders of one
+// file don't receive each other's reports. A load function must therefore
+// not load its own file with another Loader.
+func ReportSources(file fs.File, sources Sources) {
+	loadReportsMtx.Lock()
+	defer loadReportsMtx.Unlock()
+
+	if report := currentLoadReport(file); report != nil {
+		if report.sources == nil {
+			report.sources = make(Sources, len(sources))
+		}
+		maps.Copy(report.sources, sources)
+	}
+}
+
+// ReportDependencies is called by load functions to report additional files
+// the configuration loaded from file was read from, like secret files
+// referenced by environment variables.
+//
+// When the load function is called by a watching Loader for file, the
+// Loader also watches the reported files after the load succeeds and
+// invalidates the configuration when one of them changes. Every load
+// replaces the dependencies of the previous one. Reports made outside of a
+// Loader's load call are discarded.
+//
+// Example:
+//
+//	func loadConfig(file fs.File) (Config, error) {
+//	    cfg, err := dynconfig.LoadJSON[Config](file)
+//	    if err != nil {
+//	        return cfg, err
+//	    }
+//	    certFile := fs.File(cfg.CertPath)
+//	    dynconfig.ReportDependencies(file, certFile)
+//	    cfg.Cert, err = certFile.ReadAllString()
+//	    return cfg, err
+//	}
+func ReportDependencies(file fs.File, dependencies ...fs.File) {
+	loadReportsMtx.Lock()
+	defer loadReportsMtx.Unlock()
+
+	if report := currentLoadReport(file); report != nil {
+		report.dependencies = append(report.dependencies, dependencies...)
+	}
+}
+
+// ReportContent is called by load functions to report the content of file
+// they parsed, so the Loader can derive the Version of the loaded
+// configuration from it instead of reading the file again, see WithMetrics.
+// The load functions of this package report their content.
+// Reports made outside of a Loader's load call are discarded.
+//
+// Example:
+//
+//	func loadConfig(file fs.File) (Config, error) {
+//	    data, err := file.ReadAll()
+//	    if err != nil {
+//	        return Config{}, err
+//	    }
+//	    dynconfig.ReportContent(file, data)
+//	    return parseConfig(data)
+//	}
+func ReportContent(file fs.File, content []byte) {
+	loadReportsMtx.Lock()
+	defer loadReportsMtx.Unlock()
+
+	if report := currentLoadReport(file); report != nil {
+		report.content = content
+		report.contentReported = true
+	}
+}
+
+// loadReport collects what load functions report about a single load.
+type loadReport struct {
+	sources         Sources
+	dependencies    []fs.File
+	interpolated    bool // Set by Interpolate
+	content         []byte
+	contentReported bool
+}
+
+// version returns the Version of the content reported with ReportContent,
+// or an empty Version if none was reported.
+func (r *loadReport) version() Version {
+	if r == nil || !r.contentReported {
+		return ""
+	}
+	return contentVersion(r.content)
+}
+
+// fileLoads serializes the loads of a file by the Loaders of the process,
+// so everything reported for the file belongs to the load in progress.
+type fileLoads struct {
+	mtx sync.Mutex // Held during a load of the file
+	// report of the load in progress and the number of loads holding
+	// or waiting for mtx, guarded by loadReportsMtx
+	report  *loadReport
+	pending int
+}
+
+var (
+	loadReportsMtx sync.Mutex
+	loadReports    = make(map[fs.File]*fileLoads)
+)
+
+// startLoadReport waits until no other Loader loads file and then registers
+// a report collecting everything reported for file until the returned stop
+// function is called. Loaders loading the same file take turns, so each
+// receives only the reports made during its own load, while loads of
+// different files run concurrently.
+func startLoadReport(file fs.File) (report *loadReport, stop func()) {
+	loadReportsMtx.Lock()
+	loads := loadReports[file]
+	if loads == nil {
+		loads = new(fileLoads)
+		loadReports[file] = loads
+	}
+	loads.pending++
+	loadReportsMtx.Unlock()
+
+	loads.mtx.Lock()
+	report = new(loadReport)
+	loadReportsMtx.Lock()
+	loads.report = report
+	loadReportsMtx.Unlock()
+
+	return report, func() {
+		loadReportsMtx.Lock()
+		loads.report = nil
+		loads.pending--
+		if loads.pending == 0 {
+			delete(loadReports, file)
+		}
+		loadReportsMtx.Unlock()
+		loads.mtx.Unlock()
+	}
+}
+
+// currentLoadReport returns the report of the load of file in progress,
+// or nil. The caller must hold loadReportsMtx.
+func currentLoadReport(file fs.File) *loadReport {
+	if loads := loadReports[file]; loads != nil {
+		return loads.report
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package dynconfig
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ungerik/go-fs"
+)
+
+func TestLoader_Sources(t *testing.T) {
+	file := memFile(t, "config.json", `{"value":1}`)
+
+	fail := false
+	load := func(f fs.File) (counter, error) {
+		if fail {
+			ReportSources(f, Sources{"Value": {Kind: SourceDefault}})
+			return counter{}, errors.New("load failed")
+		}
+		config, err := LoadJSON[counter](f)
+		ReportSources(f, Sources{"Value": {Kind: SourceEnv, Name: "COUNTER_VALUE"}})
+		ReportSources(f, Sources{"Other": {Kind: SourceFile, Name: string(f)}})
+		return config, err
+	}
+	loader := NewLoader(file, load, nil, nil, nil, nil)
+	if loader.Sources() != nil {
+		t.Error("expected nil Sources before the first load")
+	}
+	if _, err := loader.Load(); err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	want := Sources{
+		"Value": {Kind: SourceEnv, Name: "COUNTER_VALUE"},
+		"Other": {Kind: SourceFile, Name: string(file)},
+	}
+	if got := loader.Sources(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// A failed load keeps the sources of the last known configuration
+	fail = true
+	loader.Invalidate()
+	if _, err := loader.Load(); err == nil {
+		t.Fatal("expected load error")
+	}
+	if got := loader.Sources(); !reflect.DeepEqual(got, want) {
+		t.Errorf("after failed load got %v, want %v", got, want)
+	}
+}
+
+func TestReportSources_OutsideLoader(t *testing.T) {
+	file := memFile(t, "config.json", `{"value":1}`)
+	ReportSources(file, Sources{"Value": {Kind: SourceEnv, Name: "IGNORED"}})
+
+	loader := NewLoader(file, LoadJSON[counter], nil, nil, nil, nil)
+	if _, err := loader.Load(); err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	if got := loader.Sources(); got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+	if len(loadReports) != 0 {
+		t.Errorf("%d load reports still registered", len(loadReports))
+	}
+}
+
+// TestReportSources_SameFile verifies that concurrent loads of the same file
+// by different Loaders each get only the reports of their own load.
+func TestReportSources_SameFile(t *testing.T) {
+	file := memFile(t, "config.json", `{"value":1}`)
+
+	loaders := make([]*Loader[counter], 2)
+	for i := range loaders {
+		name := fmt.Sprintf("LOADER_%d", i)
+		load := func(f fs.File) (counter, error) {
+			ReportSources(f, Sources{"Value": {Kind: SourceEnv, Name: name}})
+			time.Sleep(10 * time.Millisecond) // Would overlap with the other load
+			return LoadJSON[counter](f)
+		}
+		loaders[i] = NewLoader(file, load, nil, nil, nil, nil)
+	}
+
+	var wg sync.WaitGroup
+	for _, loader := range loaders {
+		wg.Go(func() {
+			if _, err := loader.Load(); err != nil {
+				t.Errorf("Load: %s", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	for i, loader := range loaders {
+		want := Sources{"Value": {Kind: SourceEnv, Name: fmt.Sprintf("LOADER_%d", i)}}
+		if got := loader.Sources(); !reflect.DeepEqual(got, want) {
+			t.Errorf("loader %d: got %v, want %v", i, got, want)
+		}
+	}
+	if len(loadReports) != 0 {
+		t.Errorf("%d load reports still registered", len(loadReports))
+	}
+}
+
+func TestSource_String(t *testing.T) {
+	tests := []struct {
+		source Source
+		want   string
+	}{
+		{Source{Kind: SourceFile, Name: "config.json"}, "file config.json"},
+		{Source{Kind: SourceEnv, Name: "APP_PORT"}, "env APP_PORT"},
+		{Source{Kind: SourceDefault}, "default"},
+		{Source{Kind: SourceKind(99)}, "unknown"},
+	}
+	for _, tt := range tests {
+		if got := tt.source.String(); got != tt.want {
+			t.Errorf("%#v.String() = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+// TestLoader_Dependencies verifies a change to a reported dependency
+// invalidates the configuration of a watching Loader.
+func TestLoader_Dependencies(t *testing.T) {
+	file := writeTempJSON(t, "config.json", `{"value":1}`)
+	secret := writeTempJSON(t, "secret.json", `{"value":10}`)
+
+	load := func(f fs.File) (counter, error) {
+		ReportDependencies(f, secret, secret) // Duplicates are ignored
+		return LoadJSON[counter](secret)
+	}
+	loader, err := LoadAndWatch(file, load, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadAndWatch: %s", err)
+	}
+	defer loader.Unwatch() //nolint:errcheck
+
+	if got := loader.Dependencies(); !reflect.DeepEqual(got, []fs.File{secret}) {
+		t.Fatalf("Dependencies() = %v, want [%s]", got, secret)
+	}
+	if got := loader.Get().Value; got != 10 {
+		t.Fatalf("got %d, want 10", got)
+	}
+
+	err = secret.WriteAllString(`{"value":20}`)
+	if err != nil {
+		t.Fatalf("write secret: %s", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for loader.Get().Value != 20 {
+		if time.Now().After(deadline) {
+			t.Fatal("dependency change did not invalidate the configuration")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
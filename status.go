@@ -0,0 +1,130 @@
+package dynconfig
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ungerik/go-fs"
+)
+
+// Status describes the health of a Loader, as returned by Loader.Status.
+type Status struct {
+	// File is the configuration file of the Loader.
+	File fs.File
+	// Loaded is true if a valid configuration is cached,
+	// false before the first load, after an invalidation,
+	// and while loads fail.
+	Loaded bool
+	// Loads is the number of successful loads, including the initial one.
+	Loads int
+	// LastLoad is the time of the last successful load.
+	LastLoad time.Time
+	// FileModTime is the modification time of the file at the last
+	// successful load, zero for loaders without a file.
+	FileModTime time.Time
+	// LastError is the error of the last failed load, kept after later
+	// successful loads so LastErrorTime tells when it happened.
+	LastError error
+	// LastErrorTime is the time of the last failed load.
+	LastErrorTime time.Time
+	// Degraded is true if the last load failed, so Get returns the value of
+	// the onError callback or else the last successfully loaded configuration.
+	Degraded bool
+	// Fallback is true if Degraded and the onError callback supplies
+	// the value returned by Get.
+	Fallback bool
+	// Watching is true while the file is watched for changes.
+	Watching bool
+	// Closed is true after Close.
+	Closed bool
+}
+
+// Healthy returns true if the Loader is not closed and the last load
+// didn't fail.
+func (s Status) Healthy() bool {
+	return !s.Closed && !s.Degraded
+}
+
+// Err returns nil if the Status is healthy, otherwise an error
+// describing why it isn't, for use in health checks.
+func (s Status) Err() error {
+	switch {
+	case s.Closed:
+		return ErrClosed
+	case s.Degraded && s.Fallback:
+		return fmt.Errorf("using fallback configuration of %s: %w", s.File, s.LastError)
+	case s.Degraded:
+		return fmt.Errorf("using last loaded configuration of %s: %w", s.File, s.LastError)
+	}
+	return nil
+}
+
+// loaderStatus holds the load statistics of a Loader for Status.
+type loaderStatus struct {
+	loads         int
+	lastLoad      time.Time
+	fileModTime   time.Time
+	lastError     error
+	lastErrorTime time.Time
+	degraded      bool
+}
+
+// loadSucceeded records a successful load.
+// The caller must hold l.mtx.
+func (l *Loader[T]) loadSucceeded() {
+	l.status.loads++
+	l.status.lastLoad = time.Now()
+	l.status.degraded = false
+	if l.file != "" {
+		l.status.fileModTime = l.file.Modified()
+	}
+}
+
+// loadFailed records a failed load.
+// The caller must hold l.mtx.
+func (l *Loader[T]) loadFailed(err error) {
+	l.status.lastError = err
+	l.status.lastErrorTime = time.Now()
+	l.status.degraded = true
+}
+
+// Status returns the health of the Loader: when the configuration was last
+// loaded, the last load error, and whether Get currently returns a fallback
+// because the last load failed.
+//
+// Status doesn't load the configuration, so it is cheap enough to call from
+// every health check request.
+//
+// Safe to call on a nil Loader (returns a zero Status with Closed true).
+// Thread-safe.
+//
+// Example health check handler:
+//
+//	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+//	    if err := loader.Status().Err(); err != nil {
+//	        http.Error(w, err.Error(), http.StatusServiceUnavailable)
+//	        return
+//	    }
+//	    fmt.Fprintln(w, "ok")
+//	})
+func (l *Loader[T]) Status() Status {
+	if l == nil {
+		return Status{Closed: true}
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return Status{
+		File:          l.file,
+		Loaded:        l.loaded,
+		Loads:         l.status.loads,
+		LastLoad:      l.status.lastLoad,
+		FileModTime:   l.status.fileModTime,
+		LastError:     l.status.lastError,
+		LastErrorTime: l.status.lastErrorTime,
+		Degraded:      l.status.degraded,
+		Fallback:      l.status.degraded && l.onError != nil,
+		Watching:      l.unwatch != nil,
+		Closed:        l.closed,
+	}
+}
@@ -0,0 +1,116 @@
+package dynconfig
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoader_Status(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value": 1}`)
+	loader, err := LoadAndWatch(
+		file,
+		LoadJSON[counter],
+		nil,
+		nil,
+		func(error) counter { return counter{Value: -1} },
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("LoadAndWatch: %s", err)
+	}
+	defer loader.Close() //nolint:errcheck
+
+	status := loader.Status()
+	if !status.Healthy() || status.Err() != nil {
+		t.Errorf("Status after load not healthy: %+v", status)
+	}
+	if status.File != file || !status.Loaded || !status.Watching || status.Loads != 1 {
+		t.Errorf("Status after load = %+v", status)
+	}
+	if time.Since(status.LastLoad) > time.Minute {
+		t.Errorf("LastLoad = %s, want now", status.LastLoad)
+	}
+	if !status.FileModTime.Equal(file.Modified()) {
+		t.Errorf("FileModTime = %s, want %s", status.FileModTime, file.Modified())
+	}
+
+	// A broken file makes Get return the fallback of onError
+	err = file.WriteAllString(`not json`)
+	if err != nil {
+		t.Fatalf("write file: %s", err)
+	}
+	loader.Invalidate()
+	if got := loader.Get().Value; got != -1 {
+		t.Fatalf("Get = %d, want fallback -1", got)
+	}
+	status = loader.Status()
+	if status.Healthy() || !status.Degraded || !status.Fallback || status.Loaded {
+		t.Errorf("Status after failed load = %+v, want degraded with fallback", status)
+	}
+	if status.LastError == nil || status.LastErrorTime.IsZero() {
+		t.Errorf("Status after failed load has no error: %+v", status)
+	}
+	if err := status.Err(); !errors.Is(err, status.LastError) {
+		t.Errorf("Err() = %v, want wrapped LastError", err)
+	}
+
+	// Recovering keeps the last error for reference
+	err = file.WriteAllString(`{"value": 2}`)
+	if err != nil {
+		t.Fatalf("write file: %s", err)
+	}
+	loader.Invalidate()
+	if got := loader.Get().Value; got != 2 {
+		t.Fatalf("Get = %d, want 2", got)
+	}
+	status = loader.Status()
+	if !status.Healthy() || status.Fallback || status.Loads != 2 {
+		t.Errorf("Status after recovery = %+v", status)
+	}
+	if status.LastError == nil || status.LastLoad.Before(status.LastErrorTime) {
+		t.Errorf("Status after recovery = %+v, want last error before last load", status)
+	}
+
+	err = loader.Close()
+	if err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+	status = loader.Status()
+	if status.Healthy() || !status.Closed || status.Watching || !errors.Is(status.Err(), ErrClosed) {
+		t.Errorf("Status after Close = %+v", status)
+	}
+}
+
+func TestLoader_StatusWithoutOnError(t *testing.T) {
+	file := memFile(t, "counter.json", `{"value": 1}`)
+	loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+	loader.Get()
+
+	err := file.WriteAllString(`not json`)
+	if err != nil {
+		t.Fatalf("write file: %s", err)
+	}
+	loader.Invalidate()
+	if got := loader.Get().Value; got != 1 {
+		t.Fatalf("Get = %d, want last loaded 1", got)
+	}
+	status := loader.Status()
+	if !status.Degraded || status.Fallback {
+		t.Errorf("Status = %+v, want degraded without fallback", status)
+	}
+
+	// Writing a valid value ends the degraded state
+	err = loader.Set(counter{Value: 3})
+	if err != nil {
+		t.Fatalf("Set: %s", err)
+	}
+	if status := loader.Status(); !status.Healthy() {
+		t.Errorf("Status after Set = %+v, want healthy", status)
+	}
+
+	var nilLoader *Loader[counter]
+	if status := nilLoader.Status(); !status.Closed {
+		t.Errorf("nil Loader Status = %+v, want Closed", status)
+	}
+}
@@ -0,0 +1,246 @@
+package dynconfig
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// subscriber wraps the function passed to Subscribe so the same function
+// can be subscribed and unsubscribed more than once.
+type subscriber[T any] struct {
+	fn func(old, new T, changes []Change)
+	// field is set for WatchFieldFunc, which compares the selected values
+	// itself, so fn is also called without changes according to Diff,
+	// but not for the first configuration of the Loader.
+	field bool
+}
+
+// wants reports whether change is passed to the subscriber.
+func (s *subscriber[T]) wants(change configChange[T]) bool {
+	if s.field {
+		return !change.initial
+	}
+	return len(change.changes) > 0
+}
+
+// configChange is a change of the configuration waiting to be passed
+// to the subscribers.
+type configChange[T any] struct {
+	old, new T
+	changes  []Change
+	initial  bool // old is the zero value of a Loader without configuration
+}
+
+// Subscribe calls fn with the previous and the new configuration and their
+// Diff every time the configuration returned by Get changes, until the
+// returned unsubscribe function or Close is called.
+//
+// Changes come from loads after the file or one of its dependencies
+// changed, Reload, LoadVersioned, Set, SetIfVersion and Mutate.
+// Loads and writes that don't change the configuration according to Diff,
+// like touching the file, don't call fn. The first load after Subscribe
+// on a Loader that wasn't loaded yet is passed with the zero value of T
+// as old configuration.
+//
+// While there are subscribers, a watched Loader reloads changed files
+// immediately instead of waiting for the next Get, as does Rollback.
+// The onLoad callback is applied before the change is compared.
+//
+// The subscribers are called one change at a time in the order of the
+// changes, after the Loader's lock is released, so fn may call any method
+// of the Loader. Changes made by fn are passed to the subscribers after fn
+// returns. fn may be called from the goroutine of another caller that
+// changed the configuration, or from the file watcher.
+//
+// Safe to call on a nil Loader (the returned function does nothing).
+// Thread-safe.
+//
+// Example:
+//
+//	unsubscribe := loader.Subscribe(func(old, new Config, changes []dynconfig.Change) {
+//	    for _, change := range changes {
+//	        log.Printf("Config changed: %s", change) // Database.Pool.Max: 10 → 20
+//	    }
+//	})
+//	defer unsubscribe()
+func (l *Loader[T]) Subscribe(fn func(old, new T, changes []Change)) (unsubscribe func()) {
+	if fn == nil {
+		return func() {}
+	}
+	return l.subscribe(&subscriber[T]{fn: fn})
+}
+
+// subscribe implements Subscribe and WatchFieldFunc.
+func (l *Loader[T]) subscribe(sub *subscriber[T]) (unsubscribe func()) {
+	if l == nil {
+		return func() {}
+	}
+
+	l.mtx.Lock()
+	l.subscribers = append(l.subscribers, sub)
+	l.mtx.Unlock()
+
+	var once sync.Once
+	unsubscribe = func() {
+		once.Do(func() {
+			l.mtx.Lock()
+			defer l.mtx.Unlock()
+
+			l.subscribers = slices.DeleteFunc(l.subscribers, func(s *subscriber[T]) bool { return s == sub })
+		})
+	}
+	l.addCloseFunc(unsubscribe)
+	return unsubscribe
+}
+
+// WatchField calls fn with the previous and the new value returned by
+// selector when the configuration of loader changed and the selected values
+// differ according to ==. Changes of other parts of the configuration
+// don't call fn. It is built on Loader.Subscribe and unsubscribed by
+// calling the returned function or closing the Loader.
+//
+// The selected values are compared for every new configuration, so changes
+// of fields that Diff ignores, like unexported fields, are passed too.
+// The first configuration of a Loader that wasn't loaded yet is not passed
+// to fn and not passed to the selector as zero value of T, its selected
+// value is what the next configuration is compared to. Pointers are compared
+// by address, and a reload creates new values, so use WatchFieldDeep to
+// compare the values they point to.
+//
+// Safe to call with a nil Loader (the returned function does nothing).
+//
+// Example:
+//
+//	unsubscribe := dynconfig.WatchField(loader,
+//	    func(c Config) int { return c.Database.Pool.Max },
+//	    func(old, new int) { pool.Resize(new) },
+//	)
+//	defer unsubscribe()
+func WatchField[T any, V comparable](loader *Loader[T], selector func(T) V, fn func(old, new V)) (unsubscribe func()) {
+	return WatchFieldFunc(loader, selector, func(a, b V) bool { return a == b }, fn)
+}
+
+// WatchFieldDeep is like WatchField but compares the selected values
+// with reflect.DeepEqual, for values like slices, maps and pointers.
+//
+// Example:
+//
+//	dynconfig.WatchFieldDeep(loader,
+//	    func(c Config) []string { return c.AllowedOrigins },
+//	    func(old, new []string) { cors.SetOrigins(new) },
+//	)
+func WatchFieldDeep[T, V any](loader *Loader[T], selector func(T) V, fn func(old, new V)) (unsubscribe func()) {
+	return WatchFieldFunc(loader, selector, func(a, b V) bool { return reflect.DeepEqual(a, b) }, fn)
+}
+
+// WatchFieldFunc is like WatchField but compares the selected values
+// with the function equal.
+//
+// Example:
+//
+//	dynconfig.WatchFieldFunc(loader,
+//	    func(c Config) string { return c.LogLevel },
+//	    strings.EqualFold,
+//	    func(old, new string) { setLogLevel(new) },
+//	)
+func WatchFieldFunc[T, V any](loader *Loader[T], selector func(T) V, equal func(a, b V) bool, fn func(old, new V)) (unsubscribe func()) {
+	if selector == nil || equal == nil || fn == nil {
+		return func() {}
+	}
+	return loader.subscribe(&subscriber[T]{
+		fn: func(old, new T, _ []Change) {
+			oldValue, newValue := selector(old), selector(new)
+			if !equal(oldValue, newValue) {
+				fn(oldValue, newValue)
+			}
+		},
+		field: true,
+	})
+}
+
+// setConfig replaces the cached configuration and records the change
+// for the subscribers and the logger. The caller must hold l.mtx
+// and release it with unlock.
+func (l *Loader[T]) setConfig(config T) {
+	old, initial := l.config, !l.configured
+	l.config = config
+	l.configured = true
+
+	// The initial load is logged as "config loaded" only
+	logChanges := l.logger != nil && l.status.loads > 0 && l.logger.Enabled(context.Background(), slog.LevelDebug)
+	if len(l.subscribers) == 0 && !logChanges {
+		return
+	}
+	changes := Diff(old, config)
+	if logChanges && len(changes) > 0 {
+		descriptions := make([]string, len(changes))
+		for i, change := range changes {
+			descriptions[i] = change.String()
+		}
+		l.logAttrs(context.Background(), slog.LevelDebug, "config changed", slog.Any("changes", descriptions))
+	}
+	change := configChange[T]{old: old, new: config, changes: changes, initial: initial}
+	if slices.ContainsFunc(l.subscribers, func(s *subscriber[T]) bool { return s.wants(change) }) {
+		l.pending = append(l.pending, change)
+	}
+}
+
+// unlock unlocks l.mtx and then passes the changes recorded by setConfig
+// to the subscribers, unless another goroutine is already passing changes,
+// which then also passes these in order after its own.
+func (l *Loader[T]) unlock() {
+	if l.notifying {
+		l.mtx.Unlock()
+		return
+	}
+	l.notifying = true
+	locked := true
+	defer func() {
+		// Also reached when a subscriber panics
+		if !locked {
+			l.mtx.Lock()
+		}
+		l.notifying = false
+		l.mtx.Unlock()
+	}()
+
+	for len(l.pending) > 0 {
+		pending := l.pending
+		l.pending = nil
+		subscribers := slices.Clone(l.subscribers)
+		l.mtx.Unlock()
+		locked = false
+
+		for _, change := range pending {
+			for _, sub := range subscribers {
+				if sub.wants(change) {
+					sub.fn(change.old, change.new, change.changes)
+				}
+			}
+		}
+		l.mtx.Lock()
+		locked = true
+	}
+}
+
+// subscribed reports whether the Loader has subscribers.
+func (l *Loader[T]) subscribed() bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return len(l.subscribers) > 0
+}
+
+// fileChanged invalidates the configuration after the watched file or
+// a dependency changed and reloads it immediately if there are subscribers,
+// so they learn about the change without waiting for the next Get.
+func (l *Loader[T]) fileChanged() {
+	l.Invalidate()
+	if l.subscribed() {
+		// Load errors are handled by onError and the logger
+		_, _ = l.Load()
+	}
+}
@@ -0,0 +1,291 @@
+package dynconfig
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoader_Subscribe(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value": 1}`)
+	loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+	if _, err := loader.Load(); err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+
+	type call struct {
+		old, new int
+		changes  []string
+	}
+	var calls []call
+	unsubscribe := loader.Subscribe(func(old, new counter, changes []Change) {
+		descriptions := make([]string, len(changes))
+		for i, change := range changes {
+			descriptions[i] = change.String()
+		}
+		calls = append(calls, call{old.Value, new.Value, descriptions})
+	})
+
+	err := loader.Set(counter{Value: 2})
+	if err != nil {
+		t.Fatalf("Set: %s", err)
+	}
+	// Unchanged values are not passed
+	err = loader.Set(counter{Value: 2})
+	if err != nil {
+		t.Fatalf("Set: %s", err)
+	}
+	err = loader.Mutate(true, func(c counter) (counter, error) {
+		c.Value *= 10
+		return c, nil
+	})
+	if err != nil {
+		t.Fatalf("Mutate: %s", err)
+	}
+	err = file.WriteAllString(`{"value": 3}`)
+	if err != nil {
+		t.Fatalf("write file: %s", err)
+	}
+	err = loader.Reload()
+	if err != nil {
+		t.Fatalf("Reload: %s", err)
+	}
+
+	want := []call{
+		{1, 2, []string{"Value: 1 → 2"}},
+		{2, 20, []string{"Value: 2 → 20"}},
+		{20, 3, []string{"Value: 20 → 3"}},
+	}
+	if !slices.EqualFunc(calls, want, func(a, b call) bool {
+		return a.old == b.old && a.new == b.new && slices.Equal(a.changes, b.changes)
+	}) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+
+	unsubscribe()
+	err = loader.Set(counter{Value: 4})
+	if err != nil {
+		t.Fatalf("Set: %s", err)
+	}
+	if len(calls) != len(want) {
+		t.Errorf("subscriber called after unsubscribe: %v", calls[len(want):])
+	}
+}
+
+// TestLoader_SubscribeWatch verifies that a watched Loader with subscribers
+// reloads a changed file without a Get call.
+func TestLoader_SubscribeWatch(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value": 1}`)
+	loader, err := LoadAndWatch(file, LoadJSON[counter], nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("LoadAndWatch: %s", err)
+	}
+	defer loader.Close()
+
+	values := make(chan int, 10)
+	loader.Subscribe(func(old, new counter, changes []Change) {
+		values <- new.Value
+	})
+
+	err = file.WriteAllString(`{"value": 2}`)
+	if err != nil {
+		t.Fatalf("write file: %s", err)
+	}
+	select {
+	case value := <-values:
+		if value != 2 {
+			t.Errorf("subscriber got %d, want 2", value)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscriber not called after the file changed")
+	}
+}
+
+// TestLoader_SubscribeReentrant verifies that a subscriber can change the
+// configuration, and that its change is passed after it returns.
+func TestLoader_SubscribeReentrant(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value": 1}`)
+	loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+	if _, err := loader.Load(); err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+
+	var values []int
+	loader.Subscribe(func(old, new counter, changes []Change) {
+		values = append(values, new.Value)
+		if new.Value < 3 {
+			// Calls the subscriber again after returning
+			err := loader.Set(counter{Value: new.Value + 1})
+			if err != nil {
+				t.Errorf("Set in subscriber: %s", err)
+			}
+			values = append(values, -new.Value)
+		}
+	})
+
+	err := loader.Set(counter{Value: 2})
+	if err != nil {
+		t.Fatalf("Set: %s", err)
+	}
+	if want := []int{2, -2, 3}; !slices.Equal(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+	if got := loader.Get().Value; got != 3 {
+		t.Errorf("Get = %d, want 3", got)
+	}
+}
+
+func TestLoader_SubscribeClose(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value": 1}`)
+	loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+
+	called := false
+	loader.Subscribe(func(old, new counter, changes []Change) { called = true })
+	err := loader.Close()
+	if err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+	if loader.subscribed() {
+		t.Error("subscriber still registered after Close")
+	}
+	loader.Subscribe(func(old, new counter, changes []Change) { called = true })
+	if loader.subscribed() {
+		t.Error("Subscribe after Close registered a subscriber")
+	}
+	if called {
+		t.Error("subscriber called")
+	}
+}
+
+func TestLoader_LogChanges(t *testing.T) {
+	type config struct {
+		Port     int    `json:"port"`
+		Password string `json:"password" secret:"true"`
+	}
+	logger, recorder := newRecordingLogger()
+	file := writeTempJSON(t, "config.json", `{"port": 80, "password": "hunter2"}`)
+	loader := NewLoader(file, LoadJSON[config], SaveJSON[config](), nil, nil, nil, WithLogger(logger))
+	if _, err := loader.Load(); err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	err := loader.Set(config{Port: 8080, Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("Set: %s", err)
+	}
+
+	records := recorder.records(t, "config changed")
+	if len(records) != 1 {
+		t.Fatalf("logged %d config changed records, want 1 for the Set only", len(records))
+	}
+	changes, _ := records[0]["changes"].([]any)
+	var got []string
+	for _, change := range changes {
+		got = append(got, change.(string))
+	}
+	if want := []string{"Port: 80 → 8080", "Password: secret changed"}; !slices.Equal(got, want) {
+		t.Errorf("changes = %q, want %q", got, want)
+	}
+	if strings.Contains(recorder.buf.String(), "correct horse") {
+		t.Error("logged the secret")
+	}
+}
+
+func TestWatchField(t *testing.T) {
+	type config struct {
+		Port     int      `json:"port"`
+		Level    string   `json:"level"`
+		Origins  []string `json:"origins"`
+		Database *struct {
+			Host string `json:"host"`
+		} `json:"database"`
+	}
+	file := writeTempJSON(t, "config.json", `{"port": 80, "level": "info", "origins": ["a"], "database": {"host": "db"}}`)
+	loader := NewLoader(file, LoadJSON[*config], SaveJSON[*config](), nil, nil, nil)
+
+	// The selectors don't handle nil, the first load is not passed
+	var ports, levels, origins, hosts []string
+	WatchField(loader,
+		func(c *config) int { return c.Port },
+		func(old, new int) { ports = append(ports, fmt.Sprint(old, "→", new)) },
+	)
+	WatchFieldFunc(loader,
+		func(c *config) string { return c.Level },
+		strings.EqualFold,
+		func(old, new string) { levels = append(levels, old+"→"+new) },
+	)
+	WatchFieldDeep(loader,
+		func(c *config) []string { return c.Origins },
+		func(old, new []string) { origins = append(origins, fmt.Sprint(old, "→", new)) },
+	)
+	unsubscribe := WatchFieldDeep(loader,
+		func(c *config) any { return c.Database.Host },
+		func(old, new any) { hosts = append(hosts, fmt.Sprint(old, "→", new)) },
+	)
+
+	if _, err := loader.Load(); err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	steps := []struct {
+		data        string
+		unsubscribe bool
+	}{
+		{data: `{"port": 80, "level": "INFO", "origins": ["a"], "database": {"host": "db"}}`},
+		{data: `{"port": 8080, "level": "INFO", "origins": ["a", "b"], "database": {"host": "db"}}`, unsubscribe: true},
+		{data: `{"port": 8080, "level": "debug", "origins": ["a", "b"], "database": {"host": "db2"}}`},
+	}
+	for _, step := range steps {
+		err := file.WriteAllString(step.data)
+		if err != nil {
+			t.Fatalf("write file: %s", err)
+		}
+		err = loader.Reload()
+		if err != nil {
+			t.Fatalf("Reload: %s", err)
+		}
+		if step.unsubscribe {
+			unsubscribe()
+		}
+	}
+
+	if want := []string{"80→8080"}; !slices.Equal(ports, want) {
+		t.Errorf("port changes = %q, want %q", ports, want)
+	}
+	if want := []string{"INFO→debug"}; !slices.Equal(levels, want) {
+		t.Errorf("level changes = %q, want %q", levels, want)
+	}
+	if want := []string{"[a]→[a b]"}; !slices.Equal(origins, want) {
+		t.Errorf("origins changes = %q, want %q", origins, want)
+	}
+	// The change of the host comes after unsubscribe
+	if len(hosts) != 0 {
+		t.Errorf("database changes = %q, want none", hosts)
+	}
+}
+
+// TestWatchField_Unexported verifies that WatchField compares the selected
+// values itself and also passes changes of fields that Diff ignores.
+func TestWatchField_Unexported(t *testing.T) {
+	type config struct {
+		Port  int `json:"port"`
+		token string
+	}
+	file := writeTempJSON(t, "config.json", `{"port": 80}`)
+	loader := NewLoader(file, LoadJSON[config], SaveJSON[config](), nil, nil, nil)
+
+	var tokens []string
+	WatchField(loader,
+		func(c config) string { return c.token },
+		func(old, new string) { tokens = append(tokens, old+"→"+new) },
+	)
+	for _, token := range []string{"a", "b", "b"} {
+		err := loader.Set(config{Port: 80, token: token})
+		if err != nil {
+			t.Fatalf("Set: %s", err)
+		}
+	}
+	if want := []string{"a→b"}; !slices.Equal(tokens, want) {
+		t.Errorf("token changes = %q, want %q", tokens, want)
+	}
+}
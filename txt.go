@@ -1,6 +1,9 @@
 package dynconfig
 
 import (
+	"errors"
+	"fmt"
+	"maps"
 	"slices"
 	"strings"
 	"unsafe"
@@ -14,7 +17,11 @@ import (
 // effectively handling Unix (\n), Windows (\r\n), and old Mac (\r) line endings.
 //
 // You can replace this function with a custom implementation for different
-// line splitting behavior.
+// line splitting behavior. Because it is shared by every line loader in the
+// process, replace it only during initialization, before any loader runs.
+// To configure line splitting for a single loader, use the LineOptions based
+// functions like LoadStringLinesWith instead; they use SplitLines only as the
+// default when no other splitting is configured.
 //
 // Example custom implementation:
 //
@@ -28,6 +35,8 @@ import (
 //   - LoadStringLinesTrimSpace and LoadStringLinesTrimSpaceT
 //   - LoadStringLineSet and LoadStringLineSetT
 //   - LoadStringLineSetTrimSpace and LoadStringLineSetTrimSpaceT
+//   - The LineOptions based functions, if neither LineOptions.Separators nor
+//     LineOptions.Split is set
 var SplitLines = func(str string) []string {
 	// FieldsFunc splits at each run of matching runes and never emits empty
 	// fields, so a "\r\n" pair counts as a single line break (not two) and
@@ -89,7 +98,7 @@ func SaveString(file fs.File, config string) error {
 //	}
 //	// key is of type APIKey
 func LoadStringT[T ~string](file fs.File) (T, error) {
-	str, err := file.ReadAllString()
+	str, err := readContentString(file)
 	if err != nil {
 		return "", err
 	}
@@ -159,7 +168,7 @@ func SaveStringTrimSpace(file fs.File, config string) error {
 //	token, err := dynconfig.LoadStringTrimSpaceT[Token]("auth-token.txt")
 //	// token will be Token("secret123")
 func LoadStringTrimSpaceT[T ~string](file fs.File) (T, error) {
-	str, err := file.ReadAllString()
+	str, err := readContentString(file)
 	if err != nil {
 		return "", err
 	}
@@ -202,7 +211,7 @@ func SaveStringTrimSpaceT[T ~string](file fs.File, config T) error {
 //	hosts, err := dynconfig.LoadStringLines("hosts.txt")
 //	// hosts = []string{"api.example.com", "db.example.com", "cache.example.com"}
 func LoadStringLines(file fs.File) ([]string, error) {
-	str, err := file.ReadAllString()
+	str, err := readContentString(file)
 	if err != nil {
 		return nil, err
 	}
@@ -220,7 +229,8 @@ func LoadStringLines(file fs.File) ([]string, error) {
 // To keep that round-trip intact, any occurrence of the separator inside a line
 // value is replaced with a space before writing (or removed when the separator
 // is itself a space), so a value can never be split across multiple lines when
-// the file is read back.
+// the file is read back. This changes such values, so use
+// SaveStringLinesEscaped instead when every value must read back exactly.
 //
 // It is the write counterpart to LoadStringLines and is designed to be passed
 // as the save function to the constructor for use by Loader.Mutate and
@@ -355,7 +365,7 @@ func SaveStringLinesTrimSpace(sep ...string) func(file fs.File, config []string)
 //	emails, err := dynconfig.LoadStringLinesTrimSpaceT[Email]("emails.txt")
 //	// emails = []Email{"user@example.com", "admin@example.com"}
 func LoadStringLinesTrimSpaceT[T ~string](file fs.File) ([]T, error) {
-	str, err := file.ReadAllString()
+	str, err := readContentString(file)
 	if err != nil {
 		return nil, err
 	}
@@ -461,7 +471,7 @@ func SaveStringLineSet(sep ...string) func(file fs.File, config map[string]struc
 //	allowed, err := dynconfig.LoadStringLineSetT[Domain]("allowed-domains.txt")
 //	// Returns map[Domain]struct{}
 func LoadStringLineSetT[T ~string](file fs.File) (map[T]struct{}, error) {
-	str, err := file.ReadAllString()
+	str, err := readContentString(file)
 	if err != nil {
 		return nil, err
 	}
@@ -572,7 +582,7 @@ func SaveStringLineSetTrimSpace(sep ...string) func(file fs.File, config map[str
 //	blocked, err := dynconfig.LoadStringLineSetTrimSpaceT[IPAddress]("blocked-ips.txt")
 //	// blocked = map[IPAddress]struct{}{"192.168.1.1": {}, "10.0.0.1": {}}
 func LoadStringLineSetTrimSpaceT[T ~string](file fs.File) (map[T]struct{}, error) {
-	str, err := file.ReadAllString()
+	str, err := readContentString(file)
 	if err != nil {
 		return nil, err
 	}
@@ -645,3 +655,476 @@ func sanitizeLine(line, separator string) string {
 	}
 	return strings.ReplaceAll(line, separator, " ")
 }
+
+// LineOptions configures how the LoadStringLinesWith, LoadStringLineSetWith,
+// SaveStringLinesWith, and SaveStringLineSetWith functions (and their T
+// variants) split, clean up, and join lines.
+//
+// The options are captured when the load or save function is created, so every
+// loader can use its own settings and they cannot change while it is running.
+// The zero value loads like LoadStringLines and saves like SaveStringLines,
+// except that empty lines are dropped when saving so they round-trip.
+type LineOptions struct {
+	// Separators are the line breaks to split at when loading. At each
+	// position the longest matching separator wins, so
+	// []string{"\r\n", "\n"} treats a Windows line ending as a single break.
+	// Unlike the default SplitLines, consecutive separators produce empty
+	// lines, which are dropped unless KeepEmpty is set. A separator at the
+	// end of the content terminates the last line and doesn't start
+	// an empty one.
+	// If empty, Split is used.
+	Separators []string
+
+	// Split splits the file content into lines when Separators is empty.
+	// If nil, the package-level SplitLines as set when the load function is
+	// created is used, or with KeepEmpty the separators "\r\n", "\n" and
+	// "\r", because the default SplitLines drops empty lines.
+	Split func(string) []string
+
+	// Separator is written between lines when saving.
+	// If empty, the first non-empty Separators entry is used, so saved lines
+	// load again, or a single newline ("\n") without Separators.
+	// Occurrences of Separator and of every Separators entry inside a line
+	// are neutralized as described for SaveStringLines.
+	Separator string
+
+	// TrimSpace removes leading and trailing whitespace from every line.
+	TrimSpace bool
+
+	// KeepEmpty keeps empty lines (after trimming, if enabled) instead of
+	// dropping them. When saving, a separator is appended if the last line
+	// is empty, so it is loaded again.
+	KeepEmpty bool
+
+	// Unique drops duplicate lines (after trimming, if enabled) from slices,
+	// keeping the first occurrence. Sets are always unique.
+	Unique bool
+}
+
+// splitter returns the function used to split file content into lines.
+func (opts *LineOptions) splitter() func(string) []string {
+	switch {
+	case len(opts.Separators) > 0:
+		// Try longer separators first so "\r\n" wins over "\n"
+		seps := slices.Clone(opts.Separators)
+		slices.SortStableFunc(seps, func(a, b string) int { return len(b) - len(a) })
+		seps = slices.DeleteFunc(seps, func(sep string) bool { return sep == "" })
+		return func(str string) []string { return splitAtSeparators(str, seps) }
+	case opts.Split != nil:
+		return opts.Split
+	case opts.KeepEmpty:
+		return func(str string) []string { return splitAtSeparators(str, keepEmptySeparators) }
+	default:
+		return SplitLines
+	}
+}
+
+// keepEmptySeparators are the line breaks of the default SplitLines,
+// used with KeepEmpty because SplitLines drops empty lines.
+var keepEmptySeparators = []string{"\r\n", "\n", "\r"}
+
+// separator returns the separator written between lines.
+func (opts *LineOptions) separator() string {
+	if opts.Separator != "" {
+		return opts.Separator
+	}
+	for _, sep := range opts.Separators {
+		if sep != "" {
+			return sep
+		}
+	}
+	return "\n"
+}
+
+// sanitizeLine neutralizes separator and the Separators split at when
+// loading inside line, so it loads again as a single line.
+func (opts *LineOptions) sanitizeLine(line, separator string) string {
+	line = sanitizeLine(line, separator)
+	for _, sep := range opts.Separators {
+		if sep != "" && sep != separator {
+			line = sanitizeLine(line, sep)
+		}
+	}
+	return line
+}
+
+// joinLines joins lines with separator, which is appended if the last line is
+// empty, so that the loading splitter doesn't drop it.
+func (opts *LineOptions) joinLines(lines []string, separator string) string {
+	str := strings.Join(lines, separator)
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		str += separator
+	}
+	return str
+}
+
+// cleanLines trims and filters lines in place according to the options.
+// Sanitizing for the separator has to happen before, so that spaces it
+// introduces are trimmed too.
+func (opts *LineOptions) cleanLines(lines []string) []string {
+	var seen map[string]struct{}
+	if opts.Unique {
+		seen = make(map[string]struct{}, len(lines))
+	}
+	cleaned := lines[:0]
+	for _, line := range lines {
+		if opts.TrimSpace {
+			line = strings.TrimSpace(line)
+		}
+		if line == "" && !opts.KeepEmpty {
+			continue
+		}
+		if seen != nil {
+			if _, ok := seen[line]; ok {
+				continue
+			}
+			seen[line] = struct{}{}
+		}
+		cleaned = append(cleaned, line)
+	}
+	return cleaned
+}
+
+// splitAtSeparators splits str at every occurrence of any of the non-empty
+// separators, which must be sorted longest first. A separator at the end of
+// str terminates the last line instead of starting an empty one.
+func splitAtSeparators(str string, separators []string) []string {
+	var lines []string
+	start := 0
+	for i := 0; i < len(str); {
+		matched := false
+		for _, sep := range separators {
+			if strings.HasPrefix(str[i:], sep) {
+				lines = append(lines, str[start:i])
+				i += len(sep)
+				start = i
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			i++
+		}
+	}
+	if start < len(str) {
+		lines = append(lines, str[start:])
+	}
+	return lines
+}
+
+// LoadStringLinesWith returns a load function that loads the file as a slice of
+// strings, one per line, split and cleaned up as configured by opts.
+//
+// Example:
+//
+//	loader := dynconfig.MustLoadAndWatch(
+//	    "hosts.txt",
+//	    dynconfig.LoadStringLinesWith(dynconfig.LineOptions{
+//	        Separators: []string{"\r\n", "\n"},
+//	        TrimSpace:  true,
+//	        Unique:     true,
+//	    }),
+//	    nil, nil, nil, nil,
+//	)
+func LoadStringLinesWith(opts LineOptions) func(file fs.File) ([]string, error) {
+	return LoadStringLinesWithT[string](opts)
+}
+
+// LoadStringLinesWithT returns a load function that loads the file as a slice
+// of strings of type T, one per line, split and cleaned up as configured by
+// opts.
+//
+// Type T must be a string type. See LoadStringLinesWith.
+func LoadStringLinesWithT[T ~string](opts LineOptions) func(file fs.File) ([]T, error) {
+	split := opts.splitter()
+	return func(file fs.File) ([]T, error) {
+		str, err := readContentString(file)
+		if err != nil {
+			return nil, err
+		}
+		lines := opts.cleanLines(split(str))
+		return *(*[]T)(unsafe.Pointer(&lines)), nil //#nosec G103 -- unsafe OK
+	}
+}
+
+// SaveStringLinesWith returns a save function that writes a slice of strings to
+// the file, one per line, joined with opts.Separator and cleaned up as
+// configured by opts, overwriting any existing content.
+//
+// Occurrences of the separator inside a line value are neutralized as
+// described for SaveStringLines.
+// It is the write counterpart to LoadStringLinesWith.
+func SaveStringLinesWith(opts LineOptions) func(file fs.File, config []string) error {
+	separator := opts.separator()
+	return func(file fs.File, config []string) error {
+		lines := make([]string, len(config))
+		for i, line := range config {
+			lines[i] = opts.sanitizeLine(line, separator)
+		}
+		return file.WriteAllString(opts.joinLines(opts.cleanLines(lines), separator))
+	}
+}
+
+// SaveStringLinesWithT returns a save function that writes a slice of strings
+// of type T to the file as configured by opts.
+//
+// Type T must be a string type. It is the write counterpart to
+// LoadStringLinesWithT and delegates to SaveStringLinesWith.
+func SaveStringLinesWithT[T ~string](opts LineOptions) func(file fs.File, config []T) error {
+	save := SaveStringLinesWith(opts)
+	return func(file fs.File, config []T) error {
+		return save(file, *(*[]string)(unsafe.Pointer(&config))) //#nosec G103 -- unsafe OK
+	}
+}
+
+// LoadStringLineSetWith returns a load function that loads the file as a unique
+// set of strings, one per line, split and cleaned up as configured by opts.
+// The Unique option has no effect because sets are always unique.
+func LoadStringLineSetWith(opts LineOptions) func(file fs.File) (map[string]struct{}, error) {
+	return LoadStringLineSetWithT[string](opts)
+}
+
+// LoadStringLineSetWithT returns a load function that loads the file as a
+// unique set of strings of type T, one per line, split and cleaned up as
+// configured by opts.
+//
+// Type T must be a string type. See LoadStringLineSetWith.
+func LoadStringLineSetWithT[T ~string](opts LineOptions) func(file fs.File) (map[T]struct{}, error) {
+	load := LoadStringLinesWithT[T](opts)
+	return func(file fs.File) (map[T]struct{}, error) {
+		lines, err := load(file)
+		if err != nil {
+			return nil, err
+		}
+		set := make(map[T]struct{}, len(lines))
+		for _, line := range lines {
+			set[line] = struct{}{}
+		}
+		return set, nil
+	}
+}
+
+// SaveStringLineSetWith returns a save function that writes a set of strings to
+// the file, one per line, joined with opts.Separator and cleaned up as
+// configured by opts, overwriting any existing content. The lines are sorted so
+// the output is deterministic regardless of map iteration order.
+//
+// It is the write counterpart to LoadStringLineSetWith.
+func SaveStringLineSetWith(opts LineOptions) func(file fs.File, config map[string]struct{}) error {
+	separator := opts.separator()
+	return func(file fs.File, config map[string]struct{}) error {
+		lines := make([]string, 0, len(config))
+		for line := range config {
+			lines = append(lines, opts.sanitizeLine(line, separator))
+		}
+		lines = opts.cleanLines(lines)
+		slices.Sort(lines)
+		lines = slices.Compact(lines) // Drop duplicates that collide after sanitizing and trimming
+		return file.WriteAllString(opts.joinLines(lines, separator))
+	}
+}
+
+// SaveStringLineSetWithT returns a save function that writes a set of strings
+// of type T to the file as configured by opts.
+//
+// Type T must be a string type. It is the write counterpart to
+// LoadStringLineSetWithT and delegates to SaveStringLineSetWith.
+func SaveStringLineSetWithT[T ~string](opts LineOptions) func(file fs.File, config map[T]struct{}) error {
+	save := SaveStringLineSetWith(opts)
+	return func(file fs.File, config map[T]struct{}) error {
+		set := make(map[string]struct{}, len(config))
+		for line := range config {
+			set[string(line)] = struct{}{}
+		}
+		return save(file, set)
+	}
+}
+
+// LoadStringLinesEscaped loads the file as a slice of strings written by
+// SaveStringLinesEscaped, one per line, undoing the backslash escapes.
+//
+// Unlike LoadStringLines it round-trips any slice losslessly: values may
+// contain line breaks, backslashes, leading or trailing whitespace, and empty
+// values are preserved. The format is:
+//
+//   - Every value is written on its own line terminated by "\n".
+//     A final line without terminator is accepted as well.
+//   - Inside a value, a backslash is written as `\\`, a newline as `\n`,
+//     and a carriage return as `\r`.
+//   - A "\r" before a line terminator is ignored, so files saved with
+//     Windows line endings load unchanged.
+//
+// Any other backslash sequence is invalid and returns an error, so a
+// hand-edited file with a stray backslash is reported instead of silently
+// misread.
+//
+// Example:
+//
+//	loader := dynconfig.MustLoadAndWatch(
+//	    "messages.txt",
+//	    dynconfig.LoadStringLinesEscaped,
+//	    dynconfig.SaveStringLinesEscaped,
+//	    nil, nil, nil,
+//	)
+//	err := loader.Set([]string{"multi\nline", `C:\path`, ""})
+//	// messages.txt contains:
+//	// multi\nline
+//	// C:\\path
+//	//
+func LoadStringLinesEscaped(file fs.File) ([]string, error) {
+	return LoadStringLinesEscapedT[string](file)
+}
+
+// SaveStringLinesEscaped writes a slice of strings to the file in the escaped
+// line format described at LoadStringLinesEscaped, overwriting any existing
+// content.
+//
+// It is the write counterpart to LoadStringLinesEscaped and guarantees that
+// loading the written file returns exactly the saved slice. No configuration
+// is needed, so it is a save function itself rather than a factory returning
+// one.
+func SaveStringLinesEscaped(file fs.File, config []string) error {
+	return file.WriteAllString(formatEscapedLines(config))
+}
+
+// LoadStringLinesEscapedT loads the file as a slice of strings of type T
+// written by SaveStringLinesEscapedT.
+//
+// Type T must be a string type. See LoadStringLinesEscaped for the format.
+func LoadStringLinesEscapedT[T ~string](file fs.File) ([]T, error) {
+	str, err := readContentString(file)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := parseEscapedLines(str)
+	if err != nil {
+		return nil, err
+	}
+	return *(*[]T)(unsafe.Pointer(&lines)), nil //#nosec G103 -- unsafe OK
+}
+
+// SaveStringLinesEscapedT writes a slice of strings of type T to the file in
+// the escaped line format described at LoadStringLinesEscaped.
+//
+// Type T must be a string type. It is the write counterpart to
+// LoadStringLinesEscapedT and delegates to SaveStringLinesEscaped.
+func SaveStringLinesEscapedT[T ~string](file fs.File, config []T) error {
+	return SaveStringLinesEscaped(file, *(*[]string)(unsafe.Pointer(&config))) //#nosec G103 -- unsafe OK
+}
+
+// LoadStringLineSetEscaped loads the file as a unique set of strings written
+// by SaveStringLineSetEscaped, one per line, undoing the backslash escapes.
+// See LoadStringLinesEscaped for the format.
+func LoadStringLineSetEscaped(file fs.File) (map[string]struct{}, error) {
+	return LoadStringLineSetEscapedT[string](file)
+}
+
+// SaveStringLineSetEscaped writes a set of strings to the file in the escaped
+// line format described at LoadStringLinesEscaped, overwriting any existing
+// content. The lines are sorted so the output is deterministic regardless of
+// map iteration order.
+//
+// It is the write counterpart to LoadStringLineSetEscaped and guarantees that
+// loading the written file returns exactly the saved set.
+func SaveStringLineSetEscaped(file fs.File, config map[string]struct{}) error {
+	lines := slices.Sorted(maps.Keys(config))
+	return file.WriteAllString(formatEscapedLines(lines))
+}
+
+// LoadStringLineSetEscapedT loads the file as a unique set of strings of type
+// T written by SaveStringLineSetEscapedT.
+//
+// Type T must be a string type. See LoadStringLinesEscaped for the format.
+func LoadStringLineSetEscapedT[T ~string](file fs.File) (map[T]struct{}, error) {
+	lines, err := LoadStringLinesEscapedT[T](file)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[T]struct{}, len(lines))
+	for _, line := range lines {
+		set[line] = struct{}{}
+	}
+	return set, nil
+}
+
+// SaveStringLineSetEscapedT writes a set of strings of type T to the file in
+// the escaped line format described at LoadStringLinesEscaped.
+//
+// Type T must be a string type. It is the write counterpart to
+// LoadStringLineSetEscapedT and delegates to SaveStringLineSetEscaped.
+func SaveStringLineSetEscapedT[T ~string](file fs.File, config map[T]struct{}) error {
+	set := make(map[string]struct{}, len(config))
+	for line := range config {
+		set[string(line)] = struct{}{}
+	}
+	return SaveStringLineSetEscaped(file, set)
+}
+
+// lineEscaper escapes the characters that would otherwise break the escaped
+// line format, with the backslash first so escapes are not escaped twice.
+var lineEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`)
+
+// formatEscapedLines returns lines in the escaped line format, each escaped
+// value terminated by a newline. The terminator (instead of a separator)
+// keeps an empty slice and a slice holding one empty string distinct.
+func formatEscapedLines(lines []string) string {
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(lineEscaper.Replace(line))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// parseEscapedLines parses the escaped line format written by
+// formatEscapedLines.
+func parseEscapedLines(str string) ([]string, error) {
+	if str == "" {
+		return []string{}, nil
+	}
+	rawLines := strings.Split(str, "\n")
+	if rawLines[len(rawLines)-1] == "" {
+		// Drop the empty string after the final terminator
+		rawLines = rawLines[:len(rawLines)-1]
+	}
+	lines := make([]string, len(rawLines))
+	for i, raw := range rawLines {
+		line, err := unescapeLine(strings.TrimSuffix(raw, "\r"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		lines[i] = line
+	}
+	return lines, nil
+}
+
+// unescapeLine undoes the escapes of a single line of the escaped line format.
+func unescapeLine(line string) (string, error) {
+	if !strings.Contains(line, `\`) {
+		return line, nil
+	}
+	var b strings.Builder
+	b.Grow(len(line))
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(line) {
+			return "", errors.New("incomplete escape sequence at end of line")
+		}
+		switch line[i] {
+		case '\\':
+			b.WriteByte('\\')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			return "", fmt.Errorf("invalid escape sequence %q", line[i-1:i+1])
+		}
+	}
+	return b.String(), nil
+}
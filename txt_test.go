@@ -2,6 +2,8 @@ package dynconfig
 
 import (
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/ungerik/go-fs"
@@ -522,3 +524,313 @@ func TestResolveLineSeparator(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadStringLinesWith(t *testing.T) {
+	tests := []struct {
+		name string
+		opts LineOptions
+		want []string
+	}{
+		{"zero options like LoadStringLines", LineOptions{}, []string{"alpha", "beta", "gamma", "alpha", "  ", "  delta  "}},
+		{"trim space", LineOptions{TrimSpace: true}, []string{"alpha", "beta", "gamma", "alpha", "delta"}},
+		{"trim space unique", LineOptions{TrimSpace: true, Unique: true}, []string{"alpha", "beta", "gamma", "delta"}},
+		{
+			"separators keep empty",
+			LineOptions{Separators: []string{"\n", "\r\n"}, KeepEmpty: true},
+			[]string{"alpha", "beta", "gamma", "alpha", "", "  ", "  delta  "},
+		},
+		{
+			"keep empty without separators",
+			LineOptions{KeepEmpty: true},
+			[]string{"alpha", "beta", "gamma", "alpha", "", "  ", "  delta  "},
+		},
+		{
+			"separators trim space",
+			LineOptions{Separators: []string{"\n", "\r\n"}, TrimSpace: true},
+			[]string{"alpha", "beta", "gamma", "alpha", "delta"},
+		},
+		{
+			"custom split",
+			LineOptions{Split: func(s string) []string { return strings.Split(s, "a") }, TrimSpace: true},
+			[]string{"lph", "bet", "g", "mm", "lph", "delt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := memFile(t, "data.txt", linesContent)
+			got, err := LoadStringLinesWith(tt.opts)(file)
+			if err != nil {
+				t.Fatalf("LoadStringLinesWith: %s", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLoadStringLinesWith_IndependentOfSplitLines verifies that the options
+// based loaders capture SplitLines when they are created, so changing the
+// global afterwards does not affect them.
+func TestLoadStringLinesWith_IndependentOfSplitLines(t *testing.T) {
+	load := LoadStringLinesWithT[host](LineOptions{})
+
+	original := SplitLines
+	t.Cleanup(func() { SplitLines = original })
+	SplitLines = func(str string) []string { return []string{str} }
+
+	file := memFile(t, "data.txt", "a\nb")
+	got, err := load(file)
+	if err != nil {
+		t.Fatalf("load: %s", err)
+	}
+	if want := []host{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSaveStringLinesWith(t *testing.T) {
+	file := memFile(t, "data.txt", "old")
+
+	opts := LineOptions{Separator: "\r\n", TrimSpace: true, Unique: true}
+	err := SaveStringLinesWithT[host](opts)(file, []host{" b ", "a", "", "b", "c\r\nd"})
+	if err != nil {
+		t.Fatalf("SaveStringLinesWithT: %s", err)
+	}
+	if got := readBack(t, file); got != "b\r\na\r\nc d" {
+		t.Errorf("got %q, want %q", got, "b\r\na\r\nc d")
+	}
+}
+
+func TestSaveStringLinesWith_KeepEmptyRoundTrip(t *testing.T) {
+	file := memFile(t, "data.txt", "")
+
+	for _, opts := range []LineOptions{
+		{Separators: []string{"\n"}, KeepEmpty: true},
+		{KeepEmpty: true},
+	} {
+		for _, want := range [][]string{{"a", "", "b"}, {"a", "b", ""}, {""}, {"", ""}} {
+			if err := SaveStringLinesWith(opts)(file, want); err != nil {
+				t.Fatalf("SaveStringLinesWith: %s", err)
+			}
+			got, err := LoadStringLinesWith(opts)(file)
+			if err != nil {
+				t.Fatalf("LoadStringLinesWith: %s", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%+v: got %q, want %q", opts, got, want)
+			}
+		}
+	}
+}
+
+func TestSaveStringLinesWith_SeparatorsRoundTrip(t *testing.T) {
+	file := memFile(t, "data.txt", "")
+
+	for _, opts := range []LineOptions{
+		{Separators: []string{";"}},
+		{Separators: []string{"", ";", ","}},
+		{Separators: []string{"\r\n", "\n"}},
+		{Separators: []string{"|"}, KeepEmpty: true},
+	} {
+		for _, lines := range [][]string{{"a", "b"}, {"a", "", "b"}, {"a;b", "c,d", "e\nf", "g|h"}} {
+			if err := SaveStringLinesWith(opts)(file, lines); err != nil {
+				t.Fatalf("SaveStringLinesWith: %s", err)
+			}
+			got, err := LoadStringLinesWith(opts)(file)
+			if err != nil {
+				t.Fatalf("LoadStringLinesWith: %s", err)
+			}
+			want := lines
+			if !opts.KeepEmpty {
+				want = slices.DeleteFunc(slices.Clone(lines), func(line string) bool { return line == "" })
+			}
+			if len(got) != len(want) {
+				t.Errorf("%+v: got %q, want %d lines like %q", opts, got, len(want), want)
+				continue
+			}
+			for i, line := range want {
+				// Separators inside a line are replaced with a space
+				for _, sep := range opts.Separators {
+					if sep != "" {
+						line = strings.ReplaceAll(line, sep, " ")
+					}
+				}
+				if got[i] != line {
+					t.Errorf("%+v: line %d = %q, want %q", opts, i, got[i], line)
+				}
+			}
+		}
+	}
+	if err := SaveStringLinesWith(LineOptions{Separators: []string{";"}})(file, []string{"a", "b"}); err != nil {
+		t.Fatalf("SaveStringLinesWith: %s", err)
+	}
+	if got := readBack(t, file); got != "a;b" {
+		t.Errorf("got %q, want %q", got, "a;b")
+	}
+}
+
+func TestLoadSaveStringLineSetWith(t *testing.T) {
+	file := memFile(t, "data.txt", "b;a; a ;;c")
+
+	opts := LineOptions{Separators: []string{";"}, Separator: ";", TrimSpace: true}
+	got, err := LoadStringLineSetWithT[host](opts)(file)
+	if err != nil {
+		t.Fatalf("LoadStringLineSetWithT: %s", err)
+	}
+	want := map[host]struct{}{"a": {}, "b": {}, "c": {}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got["a;b"] = struct{}{}
+	if err := SaveStringLineSetWithT[host](opts)(file, got); err != nil {
+		t.Fatalf("SaveStringLineSetWithT: %s", err)
+	}
+	if content := readBack(t, file); content != "a;a b;b;c" {
+		t.Errorf("got %q, want %q", content, "a;a b;b;c")
+	}
+}
+
+func TestSplitAtSeparators(t *testing.T) {
+	tests := []struct {
+		str  string
+		seps []string
+		want []string
+	}{
+		{"", []string{"\n"}, nil},
+		{"\n", []string{"\n"}, []string{""}},
+		{"a\r\nb\nc", []string{"\r\n", "\n"}, []string{"a", "b", "c"}},
+		{"a\nb\n", []string{"\n"}, []string{"a", "b"}},
+		{"a\n\nb\n\n", []string{"\n"}, []string{"a", "", "b", ""}},
+		{"a, b,c", []string{", ", ","}, []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		if got := splitAtSeparators(tt.str, tt.seps); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitAtSeparators(%q, %q) = %q, want %q", tt.str, tt.seps, got, tt.want)
+		}
+	}
+}
+
+func TestSaveLoadStringLinesEscaped(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		content string
+	}{
+		{"empty slice", []string{}, ""},
+		{"single empty string", []string{""}, "\n"},
+		{"plain", []string{"alpha", "beta"}, "alpha\nbeta\n"},
+		{"line breaks", []string{"a\nb", "c\r\nd"}, "a\\nb\nc\\r\\nd\n"},
+		{"backslashes", []string{`C:\path`, `\n`}, "C:\\\\path\n\\\\n\n"},
+		{"whitespace and empties", []string{"  x  ", "", " "}, "  x  \n\n \n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := memFile(t, "data.txt", "old")
+			if err := SaveStringLinesEscaped(file, tt.lines); err != nil {
+				t.Fatalf("SaveStringLinesEscaped: %s", err)
+			}
+			if got := readBack(t, file); got != tt.content {
+				t.Errorf("content = %q, want %q", got, tt.content)
+			}
+			got, err := LoadStringLinesEscaped(file)
+			if err != nil {
+				t.Fatalf("LoadStringLinesEscaped: %s", err)
+			}
+			if !reflect.DeepEqual(got, tt.lines) {
+				t.Errorf("round-trip got %q, want %q", got, tt.lines)
+			}
+		})
+	}
+}
+
+func TestLoadStringLinesEscaped_HandWritten(t *testing.T) {
+	// Windows line endings and a missing final terminator are accepted.
+	file := memFile(t, "data.txt", "a\\\\b\r\nc")
+	got, err := LoadStringLinesEscapedT[host](file)
+	if err != nil {
+		t.Fatalf("LoadStringLinesEscapedT: %s", err)
+	}
+	if want := []host{`a\b`, "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// A stray backslash is reported instead of silently misread.
+	file = memFile(t, "data.txt", "a\\tb\r\nc")
+	_, err = LoadStringLinesEscaped(file)
+	if err == nil {
+		t.Error("expected error for invalid escape sequence")
+	}
+}
+
+func TestSaveLoadStringLineSetEscaped(t *testing.T) {
+	file := memFile(t, "data.txt", "old")
+
+	want := map[host]struct{}{"b\nc": {}, "a": {}, "": {}}
+	if err := SaveStringLineSetEscapedT(file, want); err != nil {
+		t.Fatalf("SaveStringLineSetEscapedT: %s", err)
+	}
+	if got := readBack(t, file); got != "\na\nb\\nc\n" {
+		t.Errorf("content = %q, want %q", got, "\na\nb\\nc\n")
+	}
+	got, err := LoadStringLineSetEscapedT[host](file)
+	if err != nil {
+		t.Fatalf("LoadStringLineSetEscapedT: %s", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestUnescapeLine_Invalid(t *testing.T) {
+	for _, line := range []string{`a\`, `\t`, `\\\`} {
+		if _, err := unescapeLine(line); err == nil {
+			t.Errorf("unescapeLine(%q): expected error", line)
+		}
+	}
+}
+
+// FuzzEscapedLinesRoundTrip verifies Load(Save(x)) == x for arbitrary string
+// slices built from the fuzzed values.
+func FuzzEscapedLinesRoundTrip(f *testing.F) {
+	f.Add("", "", "")
+	f.Add("alpha", "beta", "gamma")
+	f.Add("a\nb", "c\r\n", `\`)
+	f.Add(`\n`, "\r", " \t ")
+	f.Add("\x00\xff", "ü", "\\\\r")
+	f.Fuzz(func(t *testing.T, a, b, c string) {
+		for _, lines := range [][]string{{}, {a}, {a, b}, {a, b, c}, {c, "", a}} {
+			got, err := parseEscapedLines(formatEscapedLines(lines))
+			if err != nil {
+				t.Fatalf("parseEscapedLines(formatEscapedLines(%q)): %s", lines, err)
+			}
+			if !reflect.DeepEqual(got, lines) {
+				t.Fatalf("round-trip of %q returned %q", lines, got)
+			}
+		}
+	})
+}
+
+// FuzzParseEscapedLines verifies that parsing arbitrary content never panics
+// and that valid content is stable under a save and load cycle.
+func FuzzParseEscapedLines(f *testing.F) {
+	f.Add("")
+	f.Add("a\nb\n")
+	f.Add("a\\nb\r\nc")
+	f.Add("\\")
+	f.Add("\\x\n")
+	f.Fuzz(func(t *testing.T, content string) {
+		lines, err := parseEscapedLines(content)
+		if err != nil {
+			return
+		}
+		again, err := parseEscapedLines(formatEscapedLines(lines))
+		if err != nil {
+			t.Fatalf("re-parse of %q: %s", lines, err)
+		}
+		if !reflect.DeepEqual(again, lines) {
+			t.Fatalf("re-parse of %q returned %q", lines, again)
+		}
+	})
+}
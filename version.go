@@ -0,0 +1,172 @@
+package dynconfig
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+
+	"github.com/ungerik/go-fs"
+)
+
+// ErrConflict is returned wrapped by SetIfVersion when the configuration
+// file was modified since the expected version was loaded.
+var ErrConflict = errors.New("version conflict")
+
+// Version identifies the content of a configuration file for optimistic
+// concurrency with LoadVersioned and SetIfVersion. It is an opaque token
+// derived from a SHA-256 hash of the file content, so it is the same for
+// equal content in every process and on every go-fs file system.
+// The empty Version stands for a file that does not exist.
+type Version string
+
+// contentVersion returns the Version of the file content data.
+func contentVersion(data []byte) Version {
+	hash := sha256.Sum256(data)
+	return Version(hex.EncodeToString(hash[:]))
+}
+
+// readContent reads the content of file and reports it with ReportContent.
+func readContent(ctx context.Context, file fs.File) ([]byte, error) {
+	data, err := file.ReadAllContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ReportContent(file, data)
+	return data, nil
+}
+
+// readContentString is like readContent but returns the content as string.
+func readContentString(file fs.File) (string, error) {
+	data, err := readContent(context.Background(), file)
+	return string(data), err
+}
+
+// fileVersion returns the Version of the current content of the
+// configuration file, or an empty Version if it does not exist.
+func (l *Loader[T]) fileVersion(ctx context.Context) (Version, error) {
+	data, err := l.file.ReadAllContext(ctx)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	return contentVersion(data), nil
+}
+
+// readFileVersioned reads the configuration file like readFile and returns
+// the Version of the content it was loaded from. Unless the last load didn't
+// report its content with ReportContent, the file is read once and the
+// reported content hashed. Otherwise the version is read before the load.
+// The caller must hold l.mtx.
+func (l *Loader[T]) readFileVersioned(ctx context.Context) (config T, report *loadReport, version Version, err error) {
+	if !l.unreportedContent {
+		config, report, err = l.readFile(ctx)
+		if err != nil {
+			return *new(T), nil, "", err
+		}
+		if report.contentReported {
+			return config, report, report.version(), nil
+		}
+		// The load function doesn't report its content, load again
+		// after reading the version
+	}
+	version, err = l.fileVersion(ctx)
+	if err != nil {
+		return *new(T), nil, "", err
+	}
+	config, report, err = l.readFile(ctx)
+	if err != nil {
+		return *new(T), nil, "", err
+	}
+	if report.contentReported {
+		version = report.version()
+	}
+	return config, report, version, nil
+}
+
+// LoadVersioned always reads the configuration file like Load after
+// Invalidate, updating the cache, and returns the configuration together
+// with the Version of the file content it was loaded from.
+//
+// Pass the version to SetIfVersion to write a modified configuration only
+// if nobody else changed the file in between. Unlike Mutate no lock is
+// held between the two calls, so an editor can take minutes.
+//
+// The version is the hash of the content parsed by the load function if it
+// reports it with ReportContent, like the load functions of this package do,
+// so the file is read only once. For other load functions the version is
+// taken before the file is parsed, so a concurrent write can only make it
+// older than the returned configuration, causing a conflict instead of a
+// lost update. On error the onError callback is not called and the zero
+// value of T is returned.
+//
+// Safe to call on a nil Loader (returns an error). Thread-safe.
+//
+// Example of an admin UI handler pair:
+//
+//	// GET: show the configuration with its version
+//	config, version, err := loader.LoadVersioned()
+//	render(w, config, version)
+//
+//	// POST: save the edited configuration
+//	newVersion, err := loader.SetIfVersion(dynconfig.Version(r.FormValue("version")), edited)
+//	if errors.Is(err, dynconfig.ErrConflict) {
+//	    http.Error(w, "configuration was changed by someone else, please reload", http.StatusConflict)
+//	    return
+//	}
+func (l *Loader[T]) LoadVersioned() (T, Version, error) {
+	if l == nil {
+		return *new(T), "", errors.New("<nil> Loader")
+	}
+	l.mtx.Lock()
+	defer l.unlock()
+
+	if l.closed {
+		return *new(T), "", ErrClosed
+	}
+	config, report, version, err := l.readFileVersioned(context.Background())
+	if err != nil {
+		l.loadFailed(err)
+		return *new(T), "", err
+	}
+	if l.onLoad != nil {
+		config = l.onLoad(config)
+	}
+	l.setConfig(config)
+	l.sources = report.sources
+	l.interpolated = report.interpolated
+	l.loaded = true
+	l.loadSucceeded()
+	_ = l.setDependencies(report.dependencies)
+	return l.config, version, nil
+}
+
+// SetIfVersion writes config like Set but only if the configuration file
+// still has the expected version returned by LoadVersioned or an earlier
+// SetIfVersion, and returns the version of the written file.
+// An empty expected version only writes if the file does not exist.
+//
+// If the file was modified in between, nothing is written and an error
+// wrapping ErrConflict is returned. The caller should then load the
+// configuration again with LoadVersioned and reapply or present the change.
+//
+// The version is compared under the same lock as the write, so on the atomic
+// path for local files the check and write can't interleave with other
+// processes using Set, Mutate or SetIfVersion. On the fallback path for
+// non-local file systems the check is only atomic within the process.
+//
+// Safe to call on a nil Loader (returns an error). Thread-safe.
+func (l *Loader[T]) SetIfVersion(expected Version, config T) (Version, error) {
+	return l.SetIfVersionContext(context.Background(), expected, config)
+}
+
+// SetIfVersionContext is like SetIfVersion but gives up waiting for the
+// write lock when ctx ends, returning a *LockTimeoutError. See MutateContext.
+//
+// Safe to call on a nil Loader (returns an error). Thread-safe.
+func (l *Loader[T]) SetIfVersionContext(ctx context.Context, expected Version, config T) (Version, error) {
+	return l.set(ctx, "SetIfVersion()", &expected, config)
+}
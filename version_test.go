@@ -0,0 +1,165 @@
+package dynconfig
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ungerik/go-fs"
+)
+
+func TestSetIfVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		file func(t *testing.T) fs.File
+	}{
+		{
+			name: "local",
+			file: func(t *testing.T) fs.File { return writeTempJSON(t, "counter.json", `{"value": 1}`) },
+		},
+		{
+			name: "memfs",
+			file: func(t *testing.T) fs.File { return memFile(t, "counter.json", `{"value": 1}`) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := tt.file(t)
+			loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+
+			config, version, err := loader.LoadVersioned()
+			if err != nil {
+				t.Fatalf("LoadVersioned: %s", err)
+			}
+			if config.Value != 1 || version == "" {
+				t.Fatalf("LoadVersioned = %d, %q, want 1 and a version", config.Value, version)
+			}
+
+			config.Value = 2
+			newVersion, err := loader.SetIfVersion(version, config)
+			if err != nil {
+				t.Fatalf("SetIfVersion: %s", err)
+			}
+			if newVersion == version {
+				t.Errorf("SetIfVersion returned the unchanged version %q", newVersion)
+			}
+			_, reloaded, err := loader.LoadVersioned()
+			if err != nil {
+				t.Fatalf("LoadVersioned: %s", err)
+			}
+			if reloaded != newVersion {
+				t.Errorf("LoadVersioned version = %q, want %q returned by SetIfVersion", reloaded, newVersion)
+			}
+
+			// The old version is stale now
+			_, err = loader.SetIfVersion(version, counter{Value: 3})
+			if !errors.Is(err, ErrConflict) {
+				t.Fatalf("SetIfVersion with stale version error = %v, want ErrConflict", err)
+			}
+			if got := loader.Get().Value; got != 2 {
+				t.Errorf("cached value = %d, want 2", got)
+			}
+		})
+	}
+}
+
+func TestSetIfVersion_ModifiedByOther(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value": 1}`)
+	editor := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+	other := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+
+	_, version, err := editor.LoadVersioned()
+	if err != nil {
+		t.Fatalf("LoadVersioned: %s", err)
+	}
+	err = other.Set(counter{Value: 5})
+	if err != nil {
+		t.Fatalf("Set: %s", err)
+	}
+
+	_, err = editor.SetIfVersion(version, counter{Value: 2})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("SetIfVersion error = %v, want ErrConflict", err)
+	}
+	if got := readValue(t, file.LocalPath()); got != 5 {
+		t.Errorf("on-disk value = %d, want 5 written by the other loader", got)
+	}
+
+	// Restoring the same content restores the version
+	err = file.WriteAllString(`{"value": 1}`)
+	if err != nil {
+		t.Fatalf("restore file: %s", err)
+	}
+	_, err = editor.SetIfVersion(version, counter{Value: 2})
+	if err != nil {
+		t.Fatalf("SetIfVersion after restoring the content: %s", err)
+	}
+}
+
+func TestSetIfVersion_Create(t *testing.T) {
+	file := fs.File(filepath.Join(t.TempDir(), "counter.json"))
+	loader := NewLoader(file, LoadJSON[counter], SaveJSON[counter](), nil, nil, nil)
+
+	version, err := loader.SetIfVersion("", counter{Value: 1})
+	if err != nil {
+		t.Fatalf("SetIfVersion creating the file: %s", err)
+	}
+	if version == "" {
+		t.Error("SetIfVersion returned an empty version for the created file")
+	}
+	_, err = loader.SetIfVersion("", counter{Value: 2})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("SetIfVersion on existing file error = %v, want ErrConflict", err)
+	}
+	if got := readValue(t, file.LocalPath()); got != 1 {
+		t.Errorf("on-disk value = %d, want 1", got)
+	}
+}
+
+func TestLoadVersioned_SingleRead(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value": 1}`)
+	loads := 0
+	load := func(f fs.File) (counter, error) {
+		loads++
+		return LoadJSON[counter](f)
+	}
+	loader := NewLoader(file, load, SaveJSON[counter](), nil, nil, nil)
+
+	_, version, err := loader.LoadVersioned()
+	if err != nil {
+		t.Fatalf("LoadVersioned: %s", err)
+	}
+	if want := contentVersion([]byte(`{"value": 1}`)); version != want {
+		t.Errorf("version = %q, want %q", version, want)
+	}
+	if loads != 1 {
+		t.Errorf("load called %d times, want once", loads)
+	}
+}
+
+func TestLoadVersioned_UnreportedContent(t *testing.T) {
+	file := writeTempJSON(t, "counter.json", `{"value": 1}`)
+	loads := 0
+	load := func(f fs.File) (c counter, err error) {
+		loads++
+		err = f.ReadJSON(context.Background(), &c)
+		return c, err
+	}
+	loader := NewLoader(file, load, SaveJSON[counter](), nil, nil, nil)
+	want := contentVersion([]byte(`{"value": 1}`))
+
+	// The first load finds out that load doesn't report its content
+	for i, wantLoads := range []int{2, 3} {
+		_, version, err := loader.LoadVersioned()
+		if err != nil {
+			t.Fatalf("LoadVersioned: %s", err)
+		}
+		if version != want {
+			t.Errorf("LoadVersioned %d version = %q, want %q", i, version, want)
+		}
+		if loads != wantLoads {
+			t.Errorf("LoadVersioned %d: load called %d times, want %d", i, loads, wantLoads)
+		}
+	}
+}
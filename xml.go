@@ -2,6 +2,8 @@ package dynconfig
 
 import (
 	"context"
+	"encoding/xml"
+	"fmt"
 
 	"github.com/ungerik/go-fs"
 )
@@ -34,10 +36,21 @@ import (
 //	config := loader.Get()
 //	fmt.Printf("DB: %s, Port: %d\n", config.Database, config.Port)
 func LoadXML[T any](file fs.File) (config T, err error) {
-	err = file.ReadXML(context.Background(), &config)
+	return LoadXMLContext[T](context.Background(), file)
+}
+
+// LoadXMLContext is the context-aware variant of LoadXML for
+// NewLoaderContext and LoadAndWatchContext. ctx is passed to the go-fs file
+// system, so reading a remote file can be canceled.
+func LoadXMLContext[T any](ctx context.Context, file fs.File) (config T, err error) {
+	data, err := readContent(ctx, file)
 	if err != nil {
 		return *new(T), err
 	}
+	err = xml.Unmarshal(data, &config)
+	if err != nil {
+		return *new(T), fmt.Errorf("%w because: %w", fs.ErrUnmarshalXML, err)
+	}
 	return config, nil
 }
 
@@ -73,7 +86,15 @@ func LoadXML[T any](file fs.File) (config T, err error) {
 //	    return cfg, nil
 //	})
 func SaveXML[T any](indent ...string) func(file fs.File, config T) error {
-	return func(file fs.File, config T) error {
-		return file.WriteXML(context.Background(), config, indent...)
+	return BackgroundSave(SaveXMLContext[T](indent...))
+}
+
+// SaveXMLContext is the context-aware variant of SaveXML for
+// NewLoaderContext and LoadAndWatchContext. The context passed to
+// MutateContext, SetContext or SetIfVersionContext is passed to the go-fs
+// file system, so writing a remote file can be canceled.
+func SaveXMLContext[T any](indent ...string) SaveContextFunc[T] {
+	return func(ctx context.Context, file fs.File, config T) error {
+		return file.WriteXML(ctx, config, indent...)
 	}
 }